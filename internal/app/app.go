@@ -1,20 +1,21 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"forum/internal/controller/http1"
 	"forum/internal/repository"
 	"forum/internal/server"
 	"forum/internal/service"
+	"forum/pkg/blobstore"
 	"forum/pkg/config"
 	"forum/pkg/database"
+	smpljwt "forum/pkg/smplJwt"
 	"io"
 	"log"
 	"os"
 )
 
-const secret string = "secret"
-
 func Run(cfg *config.Conf) {
 	// Prepare logger
 	file, err := os.OpenFile("logfile.txt", os.O_APPEND|os.O_RDWR|os.O_CREATE, 0644)
@@ -40,11 +41,52 @@ func Run(cfg *config.Conf) {
 		}
 	}()
 
+	// Apply pending migrations
+	applied, err := database.MigrateUp(db, cfg.Database.SchemeDir)
+	if err != nil {
+		log.Fatalf("error occured while migrating database: %s", err.Error())
+		return
+	}
+	for _, m := range applied {
+		log.Printf("migration applied: %02d_%s", m.Version, m.Name)
+	}
+	if len(applied) == 0 {
+		log.Println("database schema is up to date")
+	}
+
+	// Prepare signing keys
+	keys, err := newKeySet(&cfg.JWT)
+	if err != nil {
+		log.Fatalf("error occured while loading jwt keys: %s", err.Error())
+		return
+	}
+
+	// Prepare attachment storage
+	store, err := blobstore.NewLocalStore(cfg.Uploads.Dir)
+	if err != nil {
+		log.Fatalf("error occured while preparing upload storage: %s", err.Error())
+		return
+	}
+
 	// Prepare router <- -> service  <- -> repository
 	repo := repository.NewRepository(db)
-	service := service.NewService(repo, secret)
-	handler := http1.NewHandler(service, secret)
+	service := service.NewService(repo, keys, cfg, store)
+	handler := http1.NewHandler(service, keys, cfg)
+
+	// Publish scheduled posts in the background
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runScheduler(ctx, service.Post, cfg.Posts.PublishInterval.Duration)
+
 	server := new(server.Server)
 	// Start listening server
 	log.Fatalf("error occured while listening server: %s", server.Run(&cfg.API, handler.InitRoutes(cfg)))
 }
+
+func newKeySet(c *config.JWT) (*smpljwt.KeySet, error) {
+	keys := make(map[string]string, len(c.Keys))
+	for _, key := range c.Keys {
+		keys[key.ID] = key.Secret
+	}
+	return smpljwt.NewKeySet(c.ActiveKey, keys)
+}
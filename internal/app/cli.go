@@ -0,0 +1,230 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"forum/internal/entity"
+	"forum/internal/repository"
+	"forum/internal/service"
+	"forum/pkg/config"
+	"forum/pkg/database"
+	"os"
+	"strings"
+)
+
+// Migrate handles `forum migrate up|down|status`.
+func Migrate(cfg *config.Conf, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: forum migrate up|down|status")
+	}
+	db, err := database.ConnectSqlte(&cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch args[0] {
+	case "up":
+		applied, err := database.MigrateUp(db, cfg.Database.SchemeDir)
+		for _, m := range applied {
+			fmt.Printf("applied %02d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("database schema is up to date")
+		}
+		return err
+	case "down":
+		flags := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		steps := flags.Int("steps", 1, "number of migrations to roll back")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *steps < 1 {
+			return errors.New("steps must be positive")
+		}
+		reverted, err := database.MigrateDown(db, cfg.Database.SchemeDir, *steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted %02d_%s\n", m.Version, m.Name)
+		}
+		return err
+	case "status":
+		migrations, err := database.MigrationStatus(db, cfg.Database.SchemeDir)
+		if err != nil {
+			return err
+		}
+		for _, m := range migrations {
+			state := "pending"
+			if m.IsApplied() {
+				state = "applied " + m.AppliedAt.Format("2006/01/02 15:04:05")
+			}
+			fmt.Printf("%02d_%s\t%s\n", m.Version, m.Name, state)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown migrate command: %s", args[0])
+}
+
+// User handles `forum user create|reset-password|set-role`.
+func User(cfg *config.Conf, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: forum user create|reset-password|set-role")
+	}
+	db, err := connectMigrated(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	repo := repository.NewRepository(db)
+	service, err := newCommandService(cfg, repo)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "create":
+		flags := flag.NewFlagSet("user create", flag.ContinueOnError)
+		email := flags.String("email", "", "email of the new user")
+		username := flags.String("username", "", "username of the new user")
+		password := flags.String("password", "", "password of the new user, read from stdin when empty")
+		role := flags.String("role", "user", "role of the new user")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if !entity.Role(*role).IsValid() {
+			return fmt.Errorf("unknown role: %s", *role)
+		}
+		if *password == "" {
+			if *password, err = readPassword(); err != nil {
+				return err
+			}
+		}
+		if _, err := service.User.CreateWithRole(ctx, entity.User{
+			Email:       *email,
+			Username:    *username,
+			Password:    *password,
+			ConfirmPass: *password,
+		}, entity.Role(*role)); err != nil {
+			return err
+		}
+		fmt.Printf("user %s created with role %s\n", *username, *role)
+		return nil
+	case "reset-password":
+		flags := flag.NewFlagSet("user reset-password", flag.ContinueOnError)
+		email := flags.String("email", "", "email of the user")
+		password := flags.String("password", "", "new password, read from stdin when empty")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *password == "" {
+			if *password, err = readPassword(); err != nil {
+				return err
+			}
+		}
+		if _, err := service.User.ResetPassword(ctx, *email, *password); err != nil {
+			return err
+		}
+		fmt.Printf("password of %s reset, all sessions revoked\n", *email)
+		return nil
+	case "set-role":
+		flags := flag.NewFlagSet("user set-role", flag.ContinueOnError)
+		email := flags.String("email", "", "email of the user")
+		role := flags.String("role", "", "new role: user, moderator or admin")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		user, _, err := repo.User.GetUserIDByEmail(ctx, *email)
+		if err != nil {
+			return err
+		}
+		if _, err := service.User.SetRole(ctx, 0, user.ID, entity.Role(*role)); err != nil {
+			return err
+		}
+		fmt.Printf("role of %s set to %s\n", *email, *role)
+		return nil
+	}
+	return fmt.Errorf("unknown user command: %s", args[0])
+}
+
+// Reputation handles `forum reputation rebuild`.
+func Reputation(cfg *config.Conf, args []string) error {
+	if len(args) != 1 || args[0] != "rebuild" {
+		return errors.New("usage: forum reputation rebuild")
+	}
+	db, err := connectMigrated(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	service, err := newCommandService(cfg, repository.NewRepository(db))
+	if err != nil {
+		return err
+	}
+	changed, _, err := service.Reputation.Rebuild(context.Background())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("reputation rebuilt, %d users changed\n", changed)
+	return nil
+}
+
+// Backup handles `forum backup <file>`.
+func Backup(cfg *config.Conf, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: forum backup <file>")
+	}
+	db, err := database.ConnectSqlte(&cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if err := database.Backup(db, args[0]); err != nil {
+		return err
+	}
+	fmt.Printf("database saved to %s\n", args[0])
+	return nil
+}
+
+// newCommandService returns the services for the maintenance commands. They do not
+// handle attachments, so the services get no blob store and the uploads directory is
+// not created.
+func newCommandService(cfg *config.Conf, repo *repository.Repository) (*service.Service, error) {
+	keys, err := newKeySet(&cfg.JWT)
+	if err != nil {
+		return nil, err
+	}
+	return service.NewService(repo, keys, cfg, nil), nil
+}
+
+// connectMigrated opens the database and makes sure that its schema matches the binary.
+func connectMigrated(cfg *config.Conf) (*sql.DB, error) {
+	db, err := database.ConnectSqlte(&cfg.Database)
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := database.MigrationStatus(db, cfg.Database.SchemeDir)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	for _, m := range migrations {
+		if !m.IsApplied() {
+			db.Close()
+			return nil, errors.New("database has pending migrations, run `forum migrate up` first")
+		}
+	}
+	return db, nil
+}
+
+func readPassword() (string, error) {
+	fmt.Print("password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,29 @@
+package app
+
+import (
+	"context"
+	"forum/internal/service"
+	"log"
+	"time"
+)
+
+// runScheduler publishes the scheduled posts that are due, right away and then every
+// interval, until the context is done.
+func runScheduler(ctx context.Context, posts service.Post, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		published, _, err := posts.PublishDuePosts(ctx)
+		if err != nil {
+			log.Printf("error occured while publishing scheduled posts: %s", err.Error())
+		}
+		for _, post := range published {
+			log.Printf("scheduled post %d of user %d published", post.PostID, post.UserID)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package http1
+
+import (
+	"encoding/json"
+	"forum/internal/entity"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (h *Handler) getAllUsers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.errorHandler(w, r, http.StatusMethodNotAllowed, "not allowed method")
+		return
+	}
+	users, status, err := h.service.User.GetAllUsers(r.Context())
+	if err != nil {
+		h.errorHandler(w, r, status, err.Error())
+		return
+	}
+	if err := json.NewEncoder(w).Encode(users); err != nil {
+		h.errorHandler(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+}
+
+func (h *Handler) setUserRole(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		h.errorHandler(w, r, http.StatusMethodNotAllowed, "not allowed method")
+		return
+	}
+	adminID := r.Context().Value("id").(int)
+	var input struct {
+		UserID uint        `json:"user_id"`
+		Role   entity.Role `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		h.errorHandler(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if input.UserID == uint(adminID) {
+		h.errorHandler(w, r, http.StatusBadRequest, "cannot change own role")
+		return
+	}
+	if status, err := h.service.User.SetRole(r.Context(), uint(adminID), input.UserID, input.Role); err != nil {
+		h.errorHandler(w, r, status, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// getAuditLog lists audit entries filtered by the actor, action, from, to (RFC 3339) and
+// limit query parameters.
+func (h *Handler) getAuditLog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.errorHandler(w, r, http.StatusMethodNotAllowed, "not allowed method")
+		return
+	}
+	query := r.URL.Query()
+	filter := entity.AuditFilter{Action: query.Get("action")}
+	if actor := query.Get("actor"); actor != "" {
+		actorID, err := strconv.ParseUint(actor, 10, 64)
+		if err != nil {
+			h.errorHandler(w, r, http.StatusBadRequest, "invalid actor")
+			return
+		}
+		filter.ActorID = uint(actorID)
+	}
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			h.errorHandler(w, r, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		filter.Limit = n
+	}
+	var err error
+	if from := query.Get("from"); from != "" {
+		if filter.From, err = time.Parse(time.RFC3339, from); err != nil {
+			h.errorHandler(w, r, http.StatusBadRequest, "invalid from")
+			return
+		}
+	}
+	if to := query.Get("to"); to != "" {
+		if filter.To, err = time.Parse(time.RFC3339, to); err != nil {
+			h.errorHandler(w, r, http.StatusBadRequest, "invalid to")
+			return
+		}
+	}
+	entries, status, err := h.service.Audit.GetAuditLog(r.Context(), filter)
+	if err != nil {
+		h.errorHandler(w, r, status, err.Error())
+		return
+	}
+	if err := json.NewEncoder(w).Encode(entries); err != nil {
+		h.errorHandler(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+}
+
+// bans lists the active bans on GET and bans a user on POST. An empty duration bans
+// the user permanently, otherwise the user is suspended for the duration ("72h").
+func (h *Handler) bans(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		bans, status, err := h.service.GetActiveBans(r.Context())
+		if err != nil {
+			h.errorHandler(w, r, status, err.Error())
+			return
+		}
+		if err := json.NewEncoder(w).Encode(bans); err != nil {
+			h.errorHandler(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+	case http.MethodPost:
+		adminID := r.Context().Value("id").(int)
+		var input struct {
+			UserID   uint   `json:"user_id"`
+			Reason   string `json:"reason"`
+			Duration string `json:"duration"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			h.errorHandler(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		if input.UserID == uint(adminID) {
+			h.errorHandler(w, r, http.StatusBadRequest, "cannot ban yourself")
+			return
+		}
+		ban := entity.Ban{UserID: input.UserID, Reason: input.Reason}
+		if input.Duration != "" {
+			duration, err := time.ParseDuration(input.Duration)
+			if err != nil {
+				h.errorHandler(w, r, http.StatusBadRequest, "invalid duration")
+				return
+			}
+			expiresAt := time.Now().Add(duration)
+			ban.ExpiresAt = &expiresAt
+		}
+		banID, status, err := h.service.BanUser(r.Context(), uint(adminID), ban)
+		if err != nil {
+			h.errorHandler(w, r, status, err.Error())
+			return
+		}
+		if err := json.NewEncoder(w).Encode(map[string]uint{"ban_id": banID}); err != nil {
+			h.errorHandler(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+	default:
+		h.errorHandler(w, r, http.StatusMethodNotAllowed, "not allowed method")
+	}
+}
+
+func (h *Handler) liftBan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		h.errorHandler(w, r, http.StatusMethodNotAllowed, "not allowed method")
+		return
+	}
+	adminID := r.Context().Value("id").(int)
+	banID, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, "/api/admin/bans/"), 10, 64)
+	if err != nil {
+		h.errorHandler(w, r, http.StatusNotFound, "invalid ban id")
+		return
+	}
+	if status, err := h.service.LiftBan(r.Context(), uint(adminID), uint(banID)); err != nil {
+		h.errorHandler(w, r, status, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
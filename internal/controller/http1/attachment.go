@@ -0,0 +1,108 @@
+package http1
+
+import (
+	"encoding/json"
+	"errors"
+	"forum/internal/entity"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// uploadAttachment stores the "file" part of a multipart request as an attachment of
+// the post /api/post/attach/{id}.
+func (h *Handler) uploadAttachment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.errorHandler(w, r, http.StatusMethodNotAllowed, "not allowed method")
+		return
+	}
+	postID, err := strconv.Atoi(r.URL.Path[len("/api/post/attach/"):])
+	if err != nil || postID <= 0 {
+		h.errorHandler(w, r, http.StatusBadRequest, "invalid post id")
+		return
+	}
+	maxSize := h.conf.Uploads.MaxSize
+	// leave room for the multipart headers around the file
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+64<<10)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		h.errorHandler(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	upload := entity.Upload{PostID: uint(postID), UserID: uint(r.Context().Value("id").(int))}
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			status := http.StatusBadRequest
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			if err == io.EOF {
+				err = errors.New("missing file")
+			}
+			h.errorHandler(w, r, status, err.Error())
+			return
+		}
+		if part.FormName() != "file" {
+			continue
+		}
+		upload.FileName = part.FileName()
+		// one byte over the limit is enough to reject the file
+		if upload.Data, err = io.ReadAll(io.LimitReader(part, maxSize+1)); err != nil {
+			h.errorHandler(w, r, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+		break
+	}
+	attachment, status, err := h.service.Attachment.Upload(r.Context(), upload)
+	if err != nil {
+		h.errorHandler(w, r, status, err.Error())
+		return
+	}
+	if err := json.NewEncoder(w).Encode(attachment); err != nil {
+		h.errorHandler(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+}
+
+// getAttachment serves /api/attachments/{id} and /api/attachments/{id}/thumbnail.
+func (h *Handler) getAttachment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.errorHandler(w, r, http.StatusMethodNotAllowed, "not allowed method")
+		return
+	}
+	strID, thumbnail := strings.CutSuffix(r.URL.Path[len("/api/attachments/"):], "/thumbnail")
+	id, err := strconv.Atoi(strID)
+	if err != nil || id <= 0 {
+		h.errorHandler(w, r, http.StatusBadRequest, "invalid attachment id")
+		return
+	}
+	attachment, content, status, err := h.service.Attachment.Open(r.Context(), uint(id), thumbnail)
+	if err != nil {
+		h.errorHandler(w, r, status, err.Error())
+		return
+	}
+	defer content.Close()
+	contentType, disposition := attachment.MIME, "attachment"
+	if thumbnail {
+		contentType = "image/png"
+	}
+	if strings.HasPrefix(contentType, "image/") {
+		disposition = "inline"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": attachment.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; sandbox")
+	if !thumbnail {
+		w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	}
+	// the headers are sent, a failed copy can only be logged
+	if _, err := io.Copy(w, content); err != nil {
+		log.Printf("attachment %d: %s", attachment.ID, err)
+	}
+}
@@ -78,3 +78,62 @@ func (h *Handler) deleteComment(w http.ResponseWriter, r *http.Request) {
 	}
 	w.WriteHeader(http.StatusOK)
 }
+
+func (h *Handler) editComment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		h.errorHandler(w, r, http.StatusMethodNotAllowed, "not allowed method")
+		return
+	}
+	userID := r.Context().Value("id").(int)
+	if userID < 0 {
+		h.errorHandler(w, r, http.StatusUnauthorized, "invalid id")
+		return
+	}
+	strCommentID := strings.TrimPrefix(r.URL.Path, "/api/comment/edit/")
+	commentID, err := strconv.ParseUint(strCommentID, 10, 64)
+	if err != nil {
+		h.errorHandler(w, r, http.StatusNotFound, fmt.Sprintf("Invalid id: %v", strCommentID))
+		return
+	}
+	input := entity.Comment{}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		h.errorHandler(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	input.CommentID = uint(commentID)
+	input.UserID = uint(userID)
+	role := r.Context().Value("role").(entity.Role)
+	if status, err := h.service.Comment.UpdateComment(r.Context(), input, role); err != nil {
+		h.errorHandler(w, r, status, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// getCommentRevisions shows the edit history of a comment to moderators.
+func (h *Handler) getCommentRevisions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.errorHandler(w, r, http.StatusMethodNotAllowed, "not allowed method")
+		return
+	}
+	strCommentID := strings.TrimPrefix(r.URL.Path, "/api/mod/comment/revisions/")
+	commentID, err := strconv.ParseUint(strCommentID, 10, 64)
+	if err != nil {
+		h.errorHandler(w, r, http.StatusNotFound, fmt.Sprintf("Invalid id: %v", strCommentID))
+		return
+	}
+	cursor, limit, err := pageParams(r)
+	if err != nil {
+		h.errorHandler(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	revisions, status, err := h.service.Comment.GetCommentRevisions(r.Context(), uint(commentID), cursor, limit)
+	if err != nil {
+		h.errorHandler(w, r, status, err.Error())
+		return
+	}
+	if err := json.NewEncoder(w).Encode(revisions); err != nil {
+		h.errorHandler(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+}
@@ -0,0 +1,86 @@
+package http1
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"forum/internal/entity"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	accessCookie  = "access_token"
+	refreshCookie = "refresh_token"
+	csrfCookie    = "csrf_token"
+	csrfHeader    = "X-CSRF-Token"
+)
+
+// getToken returns the access token from the Authorization header or, in cookie mode,
+// from the access cookie.
+func (h *Handler) getToken(r *http.Request) (token string, fromCookie bool, ok bool) {
+	if header, ok := r.Header["Authorization"]; ok {
+		headerParts := strings.Split(header[0], " ")
+		if len(headerParts) != 2 {
+			return "", false, false
+		}
+		return headerParts[1], false, true
+	}
+	if !h.conf.Cookie.Enabled {
+		return "", false, false
+	}
+	cookie, err := r.Cookie(accessCookie)
+	if err != nil || cookie.Value == "" {
+		return "", false, false
+	}
+	return cookie.Value, true, true
+}
+
+// isValidCSRF compares the csrf header with the csrf cookie for state-changing methods.
+func (h *Handler) isValidCSRF(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	cookie, err := r.Cookie(csrfCookie)
+	if err != nil || cookie.Value == "" {
+		return false
+	}
+	header := r.Header.Get(csrfHeader)
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(header)) == 1
+}
+
+func (h *Handler) setAuthCookies(w http.ResponseWriter, tokens entity.TokenPair) error {
+	csrf := make([]byte, 32)
+	if _, err := rand.Read(csrf); err != nil {
+		return err
+	}
+	refreshTTL := h.conf.JWT.RefreshTokenTTL.Duration
+	h.setCookie(w, accessCookie, tokens.AccessToken, "/", true, h.conf.JWT.TokenTTL.Duration)
+	h.setCookie(w, refreshCookie, tokens.RefreshToken, "/api/token/refresh", true, refreshTTL)
+	h.setCookie(w, csrfCookie, base64.RawURLEncoding.EncodeToString(csrf), "/", false, refreshTTL)
+	return nil
+}
+
+func (h *Handler) clearAuthCookies(w http.ResponseWriter) {
+	h.setCookie(w, accessCookie, "", "/", true, -1)
+	h.setCookie(w, refreshCookie, "", "/api/token/refresh", true, -1)
+	h.setCookie(w, csrfCookie, "", "/", false, -1)
+}
+
+func (h *Handler) setCookie(w http.ResponseWriter, name, value, path string, httpOnly bool, ttl time.Duration) {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		HttpOnly: httpOnly,
+		Secure:   h.conf.Cookie.Secure,
+		SameSite: http.SameSiteStrictMode,
+		MaxAge:   int(ttl.Seconds()),
+	}
+	if ttl < 0 {
+		cookie.MaxAge = -1
+	}
+	http.SetCookie(w, cookie)
+}
@@ -4,8 +4,8 @@ import (
 	"context"
 	"forum/internal/entity"
 	smpljwt "forum/pkg/smplJwt"
+	"net"
 	"net/http"
-	"strings"
 )
 
 func (h *Handler) corsMiddleWare(next http.Handler) http.Handler {
@@ -22,22 +22,34 @@ func (h *Handler) corsMiddleWare(next http.Handler) http.Handler {
 	})
 }
 
-func (h *Handler) identify(role uint, next http.HandlerFunc) http.HandlerFunc {
+func (h *Handler) client(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if role > entity.Roles.Guest {
-			header, ok := r.Header["Authorization"]
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+		r = r.WithContext(context.WithValue(r.Context(), "client", entity.Client{
+			IP:        ip,
+			UserAgent: r.UserAgent(),
+		}))
+		next(w, r)
+	}
+}
+
+func (h *Handler) identify(role entity.Role, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if role != entity.RoleGuest {
+			token, fromCookie, ok := h.getToken(r)
 			if !ok {
-				h.errorHandler(w, r, http.StatusUnauthorized, "empty auth header")
+				h.errorHandler(w, r, http.StatusUnauthorized, "empty or invalid auth header")
 				return
 			}
-
-			headerParts := strings.Split(header[0], " ")
-			if len(headerParts) != 2 {
-				h.errorHandler(w, r, http.StatusUnauthorized, "invalid auth header")
+			if fromCookie && !h.isValidCSRF(r) {
+				h.errorHandler(w, r, http.StatusForbidden, "invalid csrf token")
 				return
 			}
 
-			exist, err := h.service.IsTokenExist(r.Context(), headerParts[1])
+			exist, err := h.service.IsTokenExist(r.Context(), token)
 			if err != nil {
 				h.errorHandler(w, r, http.StatusInternalServerError, err.Error())
 				return
@@ -46,19 +58,35 @@ func (h *Handler) identify(role uint, next http.HandlerFunc) http.HandlerFunc {
 				h.errorHandler(w, r, http.StatusUnauthorized, "invalid token")
 				return
 			}
-			id, err := smpljwt.ParseToken(headerParts[1], h.secret)
+			id, err := smpljwt.ParseToken(token, h.keys)
 			if err != nil {
 				if err == smpljwt.ErrExpiredToken {
-					if dberr := h.service.DeleteSessionByToken(r.Context(), headerParts[1]); dberr != nil {
-						h.errorHandler(w, r, http.StatusInternalServerError, dberr.Error())
-						return
-					}
+					h.errorHandler(w, r, http.StatusUnauthorized, err.Error())
+					return
 				}
 				h.errorHandler(w, r, http.StatusUnauthorized, "invalid token")
 				return
 			}
+			if status, err := h.service.CheckBan(r.Context(), uint(id)); err != nil {
+				h.errorHandler(w, r, status, err.Error())
+				return
+			}
+			if err := h.service.TouchSession(r.Context(), token); err != nil {
+				h.errorHandler(w, r, http.StatusInternalServerError, err.Error())
+				return
+			}
+			userRole, status, err := h.service.GetRoleByUserID(r.Context(), uint(id))
+			if err != nil {
+				h.errorHandler(w, r, status, err.Error())
+				return
+			}
+			if !userRole.Includes(role) {
+				h.errorHandler(w, r, http.StatusForbidden, "forbidden for role "+string(userRole))
+				return
+			}
 			r = r.WithContext(context.WithValue(r.Context(), "id", id))
-			r = r.WithContext(context.WithValue(r.Context(), "token", headerParts[1]))
+			r = r.WithContext(context.WithValue(r.Context(), "role", userRole))
+			r = r.WithContext(context.WithValue(r.Context(), "token", token))
 			next(w, r)
 			return
 		}
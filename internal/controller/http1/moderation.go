@@ -0,0 +1,97 @@
+package http1
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type moderationInput struct {
+	PostID    uint   `json:"post_id"`
+	CommentID uint   `json:"comment_id"`
+	Locked    bool   `json:"locked"`
+	Pinned    bool   `json:"pinned"`
+	Reason    string `json:"reason"`
+}
+
+// decodeModeration checks the method and returns the moderator id with the request body.
+func (h *Handler) decodeModeration(w http.ResponseWriter, r *http.Request) (uint, moderationInput, bool) {
+	var input moderationInput
+	if r.Method != http.MethodPost {
+		h.errorHandler(w, r, http.StatusMethodNotAllowed, "not allowed method")
+		return 0, input, false
+	}
+	moderatorID := r.Context().Value("id").(int)
+	if moderatorID < 0 {
+		h.errorHandler(w, r, http.StatusUnauthorized, "invalid id")
+		return 0, input, false
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		h.errorHandler(w, r, http.StatusBadRequest, err.Error())
+		return 0, input, false
+	}
+	return uint(moderatorID), input, true
+}
+
+func (h *Handler) moderateDeletePost(w http.ResponseWriter, r *http.Request) {
+	moderatorID, input, ok := h.decodeModeration(w, r)
+	if !ok {
+		return
+	}
+	if status, err := h.service.Moderation.DeletePost(r.Context(), moderatorID, input.PostID, input.Reason); err != nil {
+		h.errorHandler(w, r, status, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) moderateDeleteComment(w http.ResponseWriter, r *http.Request) {
+	moderatorID, input, ok := h.decodeModeration(w, r)
+	if !ok {
+		return
+	}
+	if status, err := h.service.Moderation.DeleteComment(r.Context(), moderatorID, input.CommentID, input.Reason); err != nil {
+		h.errorHandler(w, r, status, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) moderateLockPost(w http.ResponseWriter, r *http.Request) {
+	moderatorID, input, ok := h.decodeModeration(w, r)
+	if !ok {
+		return
+	}
+	if status, err := h.service.Moderation.LockPost(r.Context(), moderatorID, input.PostID, input.Locked, input.Reason); err != nil {
+		h.errorHandler(w, r, status, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) moderatePinPost(w http.ResponseWriter, r *http.Request) {
+	moderatorID, input, ok := h.decodeModeration(w, r)
+	if !ok {
+		return
+	}
+	if status, err := h.service.Moderation.PinPost(r.Context(), moderatorID, input.PostID, input.Pinned, input.Reason); err != nil {
+		h.errorHandler(w, r, status, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) getModerationLog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.errorHandler(w, r, http.StatusMethodNotAllowed, "not allowed method")
+		return
+	}
+	actions, status, err := h.service.Moderation.GetActions(r.Context())
+	if err != nil {
+		h.errorHandler(w, r, status, err.Error())
+		return
+	}
+	if err := json.NewEncoder(w).Encode(actions); err != nil {
+		h.errorHandler(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+}
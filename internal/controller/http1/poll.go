@@ -0,0 +1,34 @@
+package http1
+
+import (
+	"encoding/json"
+	"forum/internal/entity"
+	"net/http"
+)
+
+func (h *Handler) votePoll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.errorHandler(w, r, http.StatusMethodNotAllowed, "not allowed method")
+		return
+	}
+	userID := r.Context().Value("id").(int)
+	if userID < 0 {
+		h.errorHandler(w, r, http.StatusUnauthorized, "invalid id")
+		return
+	}
+	var input entity.PollVote
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		h.errorHandler(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	input.UserID = uint(userID)
+	poll, status, err := h.service.Poll.Vote(r.Context(), input)
+	if err != nil {
+		h.errorHandler(w, r, status, err.Error())
+		return
+	}
+	if err := json.NewEncoder(w).Encode(poll); err != nil {
+		h.errorHandler(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+}
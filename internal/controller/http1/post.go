@@ -2,6 +2,7 @@ package http1
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"forum/internal/entity"
 	"net/http"
@@ -15,7 +16,14 @@ func (h *Handler) getALLPosts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	tag := r.URL.Path[len("/api/posts/"):]
-	posts, status, err := h.service.Post.GetAllByTag(r.Context(), tag)
+	cursor, limit, err := pageParams(r)
+	if err != nil {
+		h.errorHandler(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	sort := entity.PostSort{Mode: r.URL.Query().Get("sort"), Window: r.URL.Query().Get("window")}
+	unanswered := r.URL.Query().Get("unanswered") == "true"
+	posts, status, err := h.service.Post.GetAllByTag(r.Context(), tag, sort, unanswered, cursor, limit)
 	if err != nil {
 		h.errorHandler(w, r, status, err.Error())
 		return
@@ -26,17 +34,37 @@ func (h *Handler) getALLPosts(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// pageParams reads the cursor and limit query parameters of a paged list. A missing
+// limit is returned as 0 and replaced by the default page size.
+func pageParams(r *http.Request) (string, int, error) {
+	query := r.URL.Query()
+	limit := 0
+	if strLimit := query.Get("limit"); strLimit != "" {
+		n, err := strconv.Atoi(strLimit)
+		if err != nil || n <= 0 {
+			return "", 0, errors.New("invalid limit")
+		}
+		limit = n
+	}
+	return query.Get("cursor"), limit, nil
+}
+
 func (h *Handler) getPostbyID(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		h.errorHandler(w, r, http.StatusMethodNotAllowed, "not allowed method")
 		return
 	}
 	strPostID := r.URL.Path[len("/api/post/"):]
+	strPostID, revisions := strings.CutSuffix(strPostID, "/revisions")
 	id, err := strconv.Atoi(strPostID)
 	if err != nil {
 		h.errorHandler(w, r, http.StatusBadRequest, "invalid post id")
 		return
 	}
+	if revisions {
+		h.getPostRevisions(w, r, uint(id))
+		return
+	}
 	post, status, err := h.service.Post.GetPostByID(r.Context(), uint(id))
 	if err != nil {
 		h.errorHandler(w, r, status, err.Error())
@@ -48,6 +76,23 @@ func (h *Handler) getPostbyID(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (h *Handler) getPostRevisions(w http.ResponseWriter, r *http.Request, postID uint) {
+	cursor, limit, err := pageParams(r)
+	if err != nil {
+		h.errorHandler(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	revisions, status, err := h.service.Post.GetPostRevisions(r.Context(), postID, cursor, limit)
+	if err != nil {
+		h.errorHandler(w, r, status, err.Error())
+		return
+	}
+	if err := json.NewEncoder(w).Encode(revisions); err != nil {
+		h.errorHandler(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+}
+
 func (h *Handler) createPost(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		h.errorHandler(w, r, http.StatusMethodNotAllowed, "not allowed method")
@@ -77,6 +122,59 @@ func (h *Handler) createPost(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (h *Handler) getDrafts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.errorHandler(w, r, http.StatusMethodNotAllowed, "not allowed method")
+		return
+	}
+	userID := r.Context().Value("id").(int)
+	if userID < 0 {
+		h.errorHandler(w, r, http.StatusUnauthorized, "invalid id")
+		return
+	}
+	cursor, limit, err := pageParams(r)
+	if err != nil {
+		h.errorHandler(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	drafts, status, err := h.service.Post.GetDrafts(r.Context(), uint(userID), cursor, limit)
+	if err != nil {
+		h.errorHandler(w, r, status, err.Error())
+		return
+	}
+	if err := json.NewEncoder(w).Encode(drafts); err != nil {
+		h.errorHandler(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+}
+
+func (h *Handler) getDraft(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.errorHandler(w, r, http.StatusMethodNotAllowed, "not allowed method")
+		return
+	}
+	strPostID := strings.TrimPrefix(r.URL.Path, "/api/draft/")
+	postID, err := strconv.ParseUint(strPostID, 10, 64)
+	if err != nil {
+		h.errorHandler(w, r, http.StatusBadRequest, "invalid post id")
+		return
+	}
+	userID := r.Context().Value("id").(int)
+	if userID < 0 {
+		h.errorHandler(w, r, http.StatusUnauthorized, "invalid id")
+		return
+	}
+	draft, status, err := h.service.Post.GetDraft(r.Context(), uint(postID), uint(userID))
+	if err != nil {
+		h.errorHandler(w, r, status, err.Error())
+		return
+	}
+	if err := json.NewEncoder(w).Encode(draft); err != nil {
+		h.errorHandler(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+}
+
 func (h *Handler) deletePost(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
 		h.errorHandler(w, r, http.StatusMethodNotAllowed, "not allowed method")
@@ -93,7 +191,41 @@ func (h *Handler) deletePost(w http.ResponseWriter, r *http.Request) {
 		h.errorHandler(w, r, http.StatusUnauthorized, "invalid id")
 		return
 	}
-	h.service.Post.DeletePostByID(r.Context(), uint(postID), uint(userID))
+	if status, err := h.service.Post.DeletePostByID(r.Context(), uint(postID), uint(userID)); err != nil {
+		h.errorHandler(w, r, status, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) editPost(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		h.errorHandler(w, r, http.StatusMethodNotAllowed, "not allowed method")
+		return
+	}
+	strPostID := strings.TrimPrefix(r.URL.Path, "/api/post/edit/")
+	postID, err := strconv.ParseUint(strPostID, 10, 64)
+	if err != nil {
+		h.errorHandler(w, r, http.StatusNotFound, fmt.Sprintf("Invalid id: %v", strPostID))
+		return
+	}
+	userID := r.Context().Value("id").(int)
+	if userID < 0 {
+		h.errorHandler(w, r, http.StatusUnauthorized, "invalid id")
+		return
+	}
+	var input entity.Post
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		h.errorHandler(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	input.PostID = uint(postID)
+	input.UserID = uint(userID)
+	if status, err := h.service.Post.UpdatePost(r.Context(), input); err != nil {
+		h.errorHandler(w, r, status, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
 }
 
 func (h *Handler) votePost(w http.ResponseWriter, r *http.Request) {
@@ -119,6 +251,53 @@ func (h *Handler) votePost(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+func (h *Handler) retagPost(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.errorHandler(w, r, http.StatusMethodNotAllowed, "not allowed method")
+		return
+	}
+	userID := r.Context().Value("id").(int)
+	if userID < 0 {
+		h.errorHandler(w, r, http.StatusUnauthorized, "invalid id")
+		return
+	}
+	var input entity.Post
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		h.errorHandler(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	input.UserID = uint(userID)
+	if status, err := h.service.Post.RetagPost(r.Context(), input); err != nil {
+		h.errorHandler(w, r, status, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) acceptAnswer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.errorHandler(w, r, http.StatusMethodNotAllowed, "not allowed method")
+		return
+	}
+	userID := r.Context().Value("id").(int)
+	if userID < 0 {
+		h.errorHandler(w, r, http.StatusUnauthorized, "invalid id")
+		return
+	}
+	var input entity.AcceptedAnswer
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		h.errorHandler(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	input.UserID = uint(userID)
+	role := r.Context().Value("role").(entity.Role)
+	if status, err := h.service.Post.AcceptAnswer(r.Context(), input, role); err != nil {
+		h.errorHandler(w, r, status, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
 func (h *Handler) getAllPostsByUserID(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		h.errorHandler(w, r, http.StatusMethodNotAllowed, "not allowed method")
@@ -134,8 +313,12 @@ func (h *Handler) getAllPostsByUserID(w http.ResponseWriter, r *http.Request) {
 		h.errorHandler(w, r, http.StatusNotFound, "not found")
 		return
 	}
-
-	posts, status, err := h.service.Post.GetAllByUserID(r.Context(), uint(userID))
+	cursor, limit, err := pageParams(r)
+	if err != nil {
+		h.errorHandler(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	posts, status, err := h.service.Post.GetAllByUserID(r.Context(), uint(userID), cursor, limit)
 	if err != nil {
 		h.errorHandler(w, r, status, err.Error())
 		return
@@ -161,7 +344,12 @@ func (h *Handler) getAllLikedPostsByUserID(w http.ResponseWriter, r *http.Reques
 		h.errorHandler(w, r, http.StatusNotFound, "not found")
 		return
 	}
-	posts, status, err := h.service.Post.GetAllLikedPostsByUserID(r.Context(), uint(userID), true)
+	cursor, limit, err := pageParams(r)
+	if err != nil {
+		h.errorHandler(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	posts, status, err := h.service.Post.GetAllLikedPostsByUserID(r.Context(), uint(userID), true, cursor, limit)
 	if err != nil {
 		h.errorHandler(w, r, status, err.Error())
 		return
@@ -187,7 +375,12 @@ func (h *Handler) getAllDisLikedPostsByUserID(w http.ResponseWriter, r *http.Req
 		h.errorHandler(w, r, http.StatusNotFound, err.Error())
 		return
 	}
-	posts, status, err := h.service.Post.GetAllLikedPostsByUserID(r.Context(), uint(userID), false)
+	cursor, limit, err := pageParams(r)
+	if err != nil {
+		h.errorHandler(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	posts, status, err := h.service.Post.GetAllLikedPostsByUserID(r.Context(), uint(userID), false, cursor, limit)
 	if err != nil {
 		h.errorHandler(w, r, status, err.Error())
 		return
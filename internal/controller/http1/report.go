@@ -0,0 +1,74 @@
+package http1
+
+import (
+	"encoding/json"
+	"forum/internal/entity"
+	"net/http"
+)
+
+func (h *Handler) createReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.errorHandler(w, r, http.StatusMethodNotAllowed, "not allowed method")
+		return
+	}
+	userID := r.Context().Value("id").(int)
+	if userID < 0 {
+		h.errorHandler(w, r, http.StatusUnauthorized, "invalid id")
+		return
+	}
+	var input entity.ReportInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		h.errorHandler(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	input.ReporterID = uint(userID)
+	reportID, status, err := h.service.CreateReport(r.Context(), input)
+	if err != nil {
+		h.errorHandler(w, r, status, err.Error())
+		return
+	}
+	if err := json.NewEncoder(w).Encode(map[string]uint{"report_id": reportID}); err != nil {
+		h.errorHandler(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+}
+
+func (h *Handler) getReports(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.errorHandler(w, r, http.StatusMethodNotAllowed, "not allowed method")
+		return
+	}
+	reports, status, err := h.service.GetOpenReports(r.Context())
+	if err != nil {
+		h.errorHandler(w, r, status, err.Error())
+		return
+	}
+	if err := json.NewEncoder(w).Encode(reports); err != nil {
+		h.errorHandler(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+}
+
+// resolveReport dismisses a report or acts on it with "remove", "warn" or "ban".
+// Banning from the queue needs the same permission as the admin ban endpoints.
+func (h *Handler) resolveReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.errorHandler(w, r, http.StatusMethodNotAllowed, "not allowed method")
+		return
+	}
+	moderatorID := r.Context().Value("id").(int)
+	var input entity.ReportResolution
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		h.errorHandler(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if role := r.Context().Value("role").(entity.Role); input.Action == "ban" && !role.Can(entity.PermManageUsers) {
+		h.errorHandler(w, r, http.StatusForbidden, "forbidden for role "+string(role))
+		return
+	}
+	if status, err := h.service.ResolveReport(r.Context(), uint(moderatorID), input); err != nil {
+		h.errorHandler(w, r, status, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -5,25 +5,28 @@ import (
 	"forum/internal/entity"
 	"forum/internal/service"
 	"forum/pkg/config"
+	smpljwt "forum/pkg/smplJwt"
 	"net/http"
 	"text/template"
 )
 
 type Handler struct {
 	service *service.Service
-	secret  string
+	keys    *smpljwt.KeySet
+	conf    *config.Conf
 }
 
 type Route struct {
 	Path    string
 	Handler http.HandlerFunc
-	Role    uint
+	Role    entity.Role
 }
 
-func NewHandler(service *service.Service, secret string) *Handler {
+func NewHandler(service *service.Service, keys *smpljwt.KeySet, conf *config.Conf) *Handler {
 	return &Handler{
 		service: service,
-		secret:  secret,
+		keys:    keys,
+		conf:    conf,
 	}
 }
 
@@ -46,10 +49,10 @@ func (h *Handler) InitRoutes(conf *config.Conf) *http.ServeMux {
 	mux.HandleFunc("/api/is-valid", h.isValidToken)
 	routes := h.createRoutes()
 	for _, route := range routes {
-		if route.Role == entity.Roles.Authorized {
-			mux.Handle(route.Path, h.corsMiddleWare(h.isAlreadyIdentified(route.Handler)))
+		if route.Role == entity.RoleAnonymous {
+			mux.Handle(route.Path, h.corsMiddleWare(h.client(h.isAlreadyIdentified(route.Handler))))
 		} else {
-			mux.Handle(route.Path, h.corsMiddleWare(h.identify(route.Role, route.Handler)))
+			mux.Handle(route.Path, h.corsMiddleWare(h.client(h.identify(route.Role, route.Handler))))
 		}
 	}
 	return mux
@@ -60,77 +63,212 @@ func (h *Handler) createRoutes() []Route {
 		{
 			Path:    "/api/signup",
 			Handler: h.signUp,
-			Role:    entity.Roles.Authorized,
+			Role:    entity.RoleAnonymous,
 		},
 		{
 			Path:    "/api/signin",
 			Handler: h.signIn,
-			Role:    entity.Roles.Authorized,
+			Role:    entity.RoleAnonymous,
+		},
+		{
+			Path:    "/api/token/refresh",
+			Handler: h.refreshToken,
+			Role:    entity.RoleGuest,
 		},
 		{
 			Path:    "/api/signout",
 			Handler: h.signOut,
-			Role:    entity.Roles.User,
+			Role:    entity.RoleUser,
+		},
+		{
+			Path:    "/api/sessions",
+			Handler: h.sessions,
+			Role:    entity.RoleUser,
+		},
+		{
+			Path:    "/api/sessions/",
+			Handler: h.revokeSession,
+			Role:    entity.RoleUser,
 		},
 		{
 			Path:    "/api/profile/",
 			Handler: h.profile,
-			Role:    entity.Roles.User,
+			Role:    entity.RoleUser,
 		},
 		{
 			Path:    "/api/profile/posts/",
 			Handler: h.getAllPostsByUserID,
-			Role:    entity.Roles.User,
+			Role:    entity.RoleUser,
 		},
 		{
 			Path:    "/api/profile/liked-posts/",
 			Handler: h.getAllLikedPostsByUserID,
-			Role:    entity.Roles.User,
+			Role:    entity.RoleUser,
 		},
 		{
 			Path:    "/api/profile/disliked-posts/",
 			Handler: h.getAllDisLikedPostsByUserID,
-			Role:    entity.Roles.User,
+			Role:    entity.RoleUser,
 		},
 		{
 			Path:    "/api/post/create",
 			Handler: h.createPost,
-			Role:    entity.Roles.User,
+			Role:    entity.RoleUser,
 		},
 		{
 			Path:    "/api/posts/",
 			Handler: h.getALLPosts,
-			Role:    entity.Roles.Guest,
+			Role:    entity.RoleGuest,
 		},
 		{
 			Path:    "/api/post/",
 			Handler: h.getPostbyID,
-			Role:    entity.Roles.Guest,
+			Role:    entity.RoleGuest,
+		},
+		{
+			Path:    "/api/search",
+			Handler: h.search,
+			Role:    entity.RoleGuest,
 		},
 		{
 			Path:    "/api/post/vote",
 			Handler: h.votePost,
-			Role:    entity.Roles.User,
+			Role:    entity.RoleUser,
+		},
+		{
+			Path:    "/api/post/tags",
+			Handler: h.retagPost,
+			Role:    entity.RoleUser,
+		},
+		{
+			Path:    "/api/post/accept",
+			Handler: h.acceptAnswer,
+			Role:    entity.RoleUser,
+		},
+		{
+			Path:    "/api/poll/vote",
+			Handler: h.votePoll,
+			Role:    entity.RoleUser,
 		},
 		{
 			Path:    "/api/post/delete/",
 			Handler: h.deletePost,
-			Role:    entity.Roles.User,
+			Role:    entity.RoleUser,
+		},
+		{
+			Path:    "/api/post/edit/",
+			Handler: h.editPost,
+			Role:    entity.RoleUser,
+		},
+		{
+			Path:    "/api/drafts",
+			Handler: h.getDrafts,
+			Role:    entity.RoleUser,
+		},
+		{
+			Path:    "/api/draft/",
+			Handler: h.getDraft,
+			Role:    entity.RoleUser,
+		},
+		{
+			Path:    "/api/post/attach/",
+			Handler: h.uploadAttachment,
+			Role:    entity.RoleUser,
+		},
+		{
+			Path:    "/api/attachments/",
+			Handler: h.getAttachment,
+			Role:    entity.RoleGuest,
 		},
 		{
 			Path:    "/api/comment/create",
 			Handler: h.createComment,
-			Role:    entity.Roles.User,
+			Role:    entity.RoleUser,
 		},
 		{
 			Path:    "/api/comment/vote",
 			Handler: h.voteComment,
-			Role:    entity.Roles.User,
+			Role:    entity.RoleUser,
 		},
 		{
 			Path:    "/api/comment/delete/",
 			Handler: h.deleteComment,
-			Role:    entity.Roles.User,
+			Role:    entity.RoleUser,
+		},
+		{
+			Path:    "/api/comment/edit/",
+			Handler: h.editComment,
+			Role:    entity.RoleUser,
+		},
+		{
+			Path:    "/api/mod/post/delete",
+			Handler: h.moderateDeletePost,
+			Role:    entity.RoleModerator,
+		},
+		{
+			Path:    "/api/mod/comment/delete",
+			Handler: h.moderateDeleteComment,
+			Role:    entity.RoleModerator,
+		},
+		{
+			Path:    "/api/mod/post/lock",
+			Handler: h.moderateLockPost,
+			Role:    entity.RoleModerator,
+		},
+		{
+			Path:    "/api/mod/post/pin",
+			Handler: h.moderatePinPost,
+			Role:    entity.RoleModerator,
+		},
+		{
+			Path:    "/api/report",
+			Handler: h.createReport,
+			Role:    entity.RoleUser,
+		},
+		{
+			Path:    "/api/mod/reports",
+			Handler: h.getReports,
+			Role:    entity.RoleModerator,
+		},
+		{
+			Path:    "/api/mod/reports/resolve",
+			Handler: h.resolveReport,
+			Role:    entity.RoleModerator,
+		},
+		{
+			Path:    "/api/mod/comment/revisions/",
+			Handler: h.getCommentRevisions,
+			Role:    entity.RoleModerator,
+		},
+		{
+			Path:    "/api/mod/log",
+			Handler: h.getModerationLog,
+			Role:    entity.RoleModerator,
+		},
+		{
+			Path:    "/api/admin/users",
+			Handler: h.getAllUsers,
+			Role:    entity.RoleAdmin,
+		},
+		{
+			Path:    "/api/admin/users/role",
+			Handler: h.setUserRole,
+			Role:    entity.RoleAdmin,
+		},
+		{
+			Path:    "/api/admin/audit",
+			Handler: h.getAuditLog,
+			Role:    entity.RoleAdmin,
+		},
+		{
+			Path:    "/api/admin/bans",
+			Handler: h.bans,
+			Role:    entity.RoleAdmin,
+		},
+		{
+			Path:    "/api/admin/bans/",
+			Handler: h.liftBan,
+			Role:    entity.RoleAdmin,
 		},
 	}
 }
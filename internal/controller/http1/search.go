@@ -0,0 +1,37 @@
+package http1
+
+import (
+	"encoding/json"
+	"forum/internal/entity"
+	"net/http"
+)
+
+// search runs a full-text search with the q, tag, author, limit and cursor query
+// parameters.
+func (h *Handler) search(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.errorHandler(w, r, http.StatusMethodNotAllowed, "not allowed method")
+		return
+	}
+	cursor, limit, err := pageParams(r)
+	if err != nil {
+		h.errorHandler(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	query := r.URL.Query()
+	page, status, err := h.service.Search.Search(r.Context(), entity.SearchQuery{
+		Query:  query.Get("q"),
+		Tag:    query.Get("tag"),
+		Author: query.Get("author"),
+		Cursor: cursor,
+		Limit:  limit,
+	})
+	if err != nil {
+		h.errorHandler(w, r, status, err.Error())
+		return
+	}
+	if err := json.NewEncoder(w).Encode(page); err != nil {
+		h.errorHandler(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+}
@@ -0,0 +1,58 @@
+package http1
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// sessions lists the device sessions of the user on GET and signs out everywhere on DELETE.
+func (h *Handler) sessions(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("id").(int)
+	if userID < 0 {
+		h.errorHandler(w, r, http.StatusUnauthorized, "invalid id")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		sessions, status, err := h.service.GetSessionsByUserID(r.Context(), uint(userID))
+		if err != nil {
+			h.errorHandler(w, r, status, err.Error())
+			return
+		}
+		token := r.Context().Value("token").(string)
+		for i := range sessions {
+			sessions[i].Current = sessions[i].Token == token
+		}
+		if err := json.NewEncoder(w).Encode(sessions); err != nil {
+			h.errorHandler(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+	case http.MethodDelete:
+		if err := h.service.DeleteSessionByUserID(r.Context(), uint(userID)); err != nil {
+			h.errorHandler(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	default:
+		h.errorHandler(w, r, http.StatusMethodNotAllowed, "not allowed method")
+	}
+}
+
+func (h *Handler) revokeSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		h.errorHandler(w, r, http.StatusMethodNotAllowed, "not allowed method")
+		return
+	}
+	userID := r.Context().Value("id").(int)
+	if userID < 0 {
+		h.errorHandler(w, r, http.StatusUnauthorized, "invalid id")
+		return
+	}
+	sessionID := strings.TrimPrefix(r.URL.Path, "/api/sessions/")
+	if status, err := h.service.DeleteUserSession(r.Context(), uint(userID), sessionID); err != nil {
+		h.errorHandler(w, r, status, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -5,9 +5,9 @@ import (
 	"fmt"
 	"forum/internal/entity"
 	smpljwt "forum/pkg/smplJwt"
+	"io"
 	"net/http"
 	"strconv"
-	"strings"
 )
 
 func (h *Handler) signUp(w http.ResponseWriter, r *http.Request) {
@@ -40,19 +40,69 @@ func (h *Handler) signIn(w http.ResponseWriter, r *http.Request) {
 		h.errorHandler(w, r, http.StatusBadRequest, fmt.Sprintf("invalid json input: %v", err.Error()))
 		return
 	}
-	token, status, err := h.service.SignIn(r.Context(), input)
+	tokens, status, err := h.service.SignIn(r.Context(), input)
 	if err != nil {
 		h.errorHandler(w, r, status, err.Error())
 		return
 	}
-	if err := json.NewEncoder(w).Encode(map[string]interface{}{
-		"token": token,
-	}); err != nil {
+	h.writeTokens(w, r, tokens)
+}
+
+// writeTokens returns the token pair in the body, or sets it as cookies in cookie mode.
+func (h *Handler) writeTokens(w http.ResponseWriter, r *http.Request, tokens entity.TokenPair) {
+	if h.conf.Cookie.Enabled {
+		id, err := smpljwt.ParseToken(tokens.AccessToken, h.keys)
+		if err != nil {
+			h.errorHandler(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if err := h.setAuthCookies(w, tokens); err != nil {
+			h.errorHandler(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{
+			"user_id": id,
+		}); err != nil {
+			h.errorHandler(w, r, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	if err := json.NewEncoder(w).Encode(tokens); err != nil {
 		h.errorHandler(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 }
 
+func (h *Handler) refreshToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.errorHandler(w, r, http.StatusMethodNotAllowed, "not allowed method")
+		return
+	}
+	var input entity.TokenPair
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil && err != io.EOF {
+		h.errorHandler(w, r, http.StatusBadRequest, fmt.Sprintf("invalid json input: %v", err.Error()))
+		return
+	}
+	if input.RefreshToken == "" && h.conf.Cookie.Enabled {
+		if cookie, err := r.Cookie(refreshCookie); err == nil {
+			if !h.isValidCSRF(r) {
+				h.errorHandler(w, r, http.StatusForbidden, "invalid csrf token")
+				return
+			}
+			input.RefreshToken = cookie.Value
+		}
+	}
+	tokens, status, err := h.service.Refresh(r.Context(), input.RefreshToken)
+	if err != nil {
+		if status == http.StatusUnauthorized && h.conf.Cookie.Enabled {
+			h.clearAuthCookies(w)
+		}
+		h.errorHandler(w, r, status, err.Error())
+		return
+	}
+	h.writeTokens(w, r, tokens)
+}
+
 func (h *Handler) profile(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		h.errorHandler(w, r, http.StatusMethodNotAllowed, "not allowed method")
@@ -84,7 +134,7 @@ func (h *Handler) isValidToken(w http.ResponseWriter, r *http.Request) {
 		h.errorHandler(w, r, http.StatusMethodNotAllowed, "not allowed method")
 		return
 	}
-	header, ok := r.Header["Authorization"]
+	token, _, ok := h.getToken(r)
 	if !ok {
 		if err := json.NewEncoder(w).Encode(map[string]interface{}{
 			"checker": false,
@@ -95,18 +145,7 @@ func (h *Handler) isValidToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	headerParts := strings.Split(header[0], " ")
-	if len(headerParts) != 2 {
-		if err := json.NewEncoder(w).Encode(map[string]interface{}{
-			"checker": false,
-		}); err != nil {
-			h.errorHandler(w, r, http.StatusInternalServerError, err.Error())
-			return
-		}
-		return
-	}
-
-	exist, err := h.service.IsTokenExist(r.Context(), headerParts[1])
+	exist, err := h.service.IsTokenExist(r.Context(), token)
 	if err != nil {
 		h.errorHandler(w, r, http.StatusInternalServerError, err.Error())
 		return
@@ -120,14 +159,8 @@ func (h *Handler) isValidToken(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	_, err = smpljwt.ParseToken(headerParts[1], h.secret)
+	_, err = smpljwt.ParseToken(token, h.keys)
 	if err != nil {
-		if err == smpljwt.ErrExpiredToken {
-			if dberr := h.service.DeleteSessionByToken(r.Context(), headerParts[1]); dberr != nil {
-				h.errorHandler(w, r, http.StatusInternalServerError, dberr.Error())
-				return
-			}
-		}
 		if err := json.NewEncoder(w).Encode(map[string]interface{}{
 			"checker": false,
 		}); err != nil {
@@ -149,10 +182,14 @@ func (h *Handler) signOut(w http.ResponseWriter, r *http.Request) {
 		h.errorHandler(w, r, http.StatusMethodNotAllowed, "not allowed method")
 		return
 	}
+	userID := r.Context().Value("id").(int)
 	token := r.Context().Value("token").(string)
-	if err := h.service.DeleteSessionByToken(r.Context(), token); err != nil {
+	if err := h.service.SignOut(r.Context(), uint(userID), token); err != nil {
 		h.errorHandler(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
+	if h.conf.Cookie.Enabled {
+		h.clearAuthCookies(w)
+	}
 	w.WriteHeader(http.StatusOK)
 }
@@ -0,0 +1,28 @@
+package entity
+
+import "time"
+
+// Attachment is a file uploaded to a post. Images have their dimensions set and a
+// thumbnail.
+type Attachment struct {
+	ID           uint      `json:"id"`
+	PostID       uint      `json:"post_id"`
+	UserID       uint      `json:"user_id"`
+	FileName     string    `json:"file_name"`
+	MIME         string    `json:"mime"`
+	Size         int64     `json:"size"`
+	Width        int       `json:"width,omitempty"`
+	Height       int       `json:"height,omitempty"`
+	HasThumbnail bool      `json:"has_thumbnail"`
+	BlobKey      string    `json:"-"`
+	ThumbnailKey string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Upload is a file that UserID uploads to the post.
+type Upload struct {
+	PostID   uint
+	UserID   uint
+	FileName string
+	Data     []byte
+}
@@ -0,0 +1,44 @@
+package entity
+
+import "time"
+
+const (
+	AuditSignIn        = "sign_in"
+	AuditSignInFailed  = "sign_in_failed"
+	AuditSignOut       = "sign_out"
+	AuditSignOutAll    = "sign_out_all"
+	AuditSessionRevoke = "session_revoke"
+	AuditRefreshReuse  = "refresh_token_reuse"
+	AuditPasswordReset = "password_reset"
+	AuditRoleChange    = "role_change"
+	AuditUserBan       = "ban_user"
+	AuditBanLift       = "lift_ban"
+	AuditPostDelete    = ModDeletePost
+	AuditCommentDelete = ModDeleteComment
+	AuditCommentEdit   = "edit_comment"
+	AuditPostVote      = "post_vote"
+	AuditCommentVote   = "comment_vote"
+	AuditPollVote      = "poll_vote"
+	AuditAnswerAccept  = "accept_answer"
+)
+
+// AuditEntry is one row of the audit log. ActorID is 0 for anonymous or system actors.
+type AuditEntry struct {
+	ID         uint      `json:"id"`
+	ActorID    uint      `json:"actor_id"`
+	ActorName  string    `json:"actor"`
+	Action     string    `json:"action"`
+	TargetType string    `json:"target_type"`
+	TargetID   uint      `json:"target_id"`
+	IP         string    `json:"ip"`
+	Detail     string    `json:"detail"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type AuditFilter struct {
+	ActorID uint
+	Action  string
+	From    time.Time
+	To      time.Time
+	Limit   int
+}
@@ -0,0 +1,20 @@
+package entity
+
+import "time"
+
+// Ban blocks a user from signing in. A ban without ExpiresAt is permanent, otherwise
+// it is a suspension that ends at ExpiresAt.
+type Ban struct {
+	ID        uint       `json:"id"`
+	UserID    uint       `json:"user_id"`
+	UserName  string     `json:"username"`
+	AdminID   uint       `json:"admin_id"`
+	AdminName string     `json:"admin"`
+	Reason    string     `json:"reason"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (b Ban) IsActive(now time.Time) bool {
+	return b.ExpiresAt == nil || now.Before(*b.ExpiresAt)
+}
@@ -1,13 +1,28 @@
 package entity
 
+import "time"
+
+// Comment is a comment on a post or, when ParentID is set, a reply to another comment.
+// Depth is 0 for top-level comments. Accepted is set on the comment that the post
+// author accepted as the answer. Deleted comments that still have replies are kept
+// without their text and author.
 type Comment struct {
-	CommentID uint   `json:"comment_id"`
-	UserID    uint   `json:"user_id"`
-	UserName  string `json:"username"`
-	PostID    uint   `json:"post_id"`
-	Data      string `json:"data"`
-	Likes     uint   `json:"likes"`
-	Dislikes  uint   `json:"dislikes"`
+	CommentID      uint       `json:"comment_id"`
+	UserID         uint       `json:"user_id"`
+	UserName       string     `json:"username"`
+	UserReputation int        `json:"user_reputation"`
+	PostID         uint       `json:"post_id"`
+	ParentID       uint       `json:"parent_id"`
+	Depth          int        `json:"depth"`
+	Data           string     `json:"data"`
+	DataHTML       string     `json:"data_html"`
+	Likes          uint       `json:"likes"`
+	Dislikes       uint       `json:"dislikes"`
+	Accepted       bool       `json:"accepted"`
+	Deleted        bool       `json:"deleted"`
+	EditedAt       *time.Time `json:"edited_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
 }
 
 type CommentVote struct {
@@ -15,3 +30,22 @@ type CommentVote struct {
 	CommentID uint `json:"comment_id"`
 	Vote      int  `json:"vote"`
 }
+
+// CommentRevision is the text of a comment before the edit that EditorID made at
+// CreatedAt. Diff holds the changes of that edit.
+type CommentRevision struct {
+	ID         uint      `json:"id"`
+	CommentID  uint      `json:"comment_id"`
+	EditorID   uint      `json:"editor_id"`
+	EditorName string    `json:"editor"`
+	Data       string    `json:"data"`
+	CreatedAt  time.Time `json:"created_at"`
+	Diff       string    `json:"diff"`
+}
+
+// CommentRevisionPage is one page of the revisions of a comment, oldest first.
+// NextCursor is empty on the last page.
+type CommentRevisionPage struct {
+	Revisions  []CommentRevision `json:"revisions"`
+	NextCursor string            `json:"next_cursor"`
+}
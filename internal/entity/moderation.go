@@ -0,0 +1,24 @@
+package entity
+
+import "time"
+
+const (
+	ModDeletePost    = "delete_post"
+	ModDeleteComment = "delete_comment"
+	ModLockPost      = "lock_post"
+	ModUnlockPost    = "unlock_post"
+	ModPinPost       = "pin_post"
+	ModUnpinPost     = "unpin_post"
+	ModWarnUser      = "warn_user"
+)
+
+type ModerationAction struct {
+	ID            uint      `json:"id"`
+	ModeratorID   uint      `json:"moderator_id"`
+	ModeratorName string    `json:"moderator"`
+	Action        string    `json:"action"`
+	TargetType    string    `json:"target_type"`
+	TargetID      uint      `json:"target_id"`
+	Reason        string    `json:"reason"`
+	CreatedAt     time.Time `json:"created_at"`
+}
@@ -0,0 +1,80 @@
+package entity
+
+import "time"
+
+// PostCursor is the position of the last post of a page. An empty cursor starts at
+// the first page. Sort and Time keep the order and the reference time of the first
+// page so that scores stay comparable between pages.
+type PostCursor struct {
+	ID     uint    `json:"id"`
+	Pinned bool    `json:"pinned,omitempty"`
+	Score  float64 `json:"score,omitempty"`
+	Sort   string  `json:"sort,omitempty"`
+	Time   int64   `json:"time,omitempty"`
+}
+
+// RevisionCursor is the position of the last revision of a page of revisions.
+type RevisionCursor struct {
+	ID uint `json:"id"`
+}
+
+// PostPage is one page of a post list. NextCursor is empty on the last page.
+type PostPage struct {
+	Posts      []Post `json:"posts"`
+	NextCursor string `json:"next_cursor"`
+}
+
+const (
+	SortNew    = "new"
+	SortTop    = "top"
+	SortHot    = "hot"
+	SortActive = "active"
+)
+
+const (
+	WindowDay   = "day"
+	WindowWeek  = "week"
+	WindowMonth = "month"
+	WindowAll   = "all"
+)
+
+var windows = map[string]time.Duration{
+	WindowDay:   24 * time.Hour,
+	WindowWeek:  7 * 24 * time.Hour,
+	WindowMonth: 30 * 24 * time.Hour,
+	WindowAll:   0,
+}
+
+// PostSort is the order of a post feed. Window limits the top sort to the posts
+// created within it.
+type PostSort struct {
+	Mode   string
+	Window string
+}
+
+func (s PostSort) IsValid() bool {
+	switch s.Mode {
+	case SortNew, SortHot, SortActive:
+		return s.Window == ""
+	case SortTop:
+		_, ok := windows[s.Window]
+		return ok
+	}
+	return false
+}
+
+// Since returns the oldest creation time that the window allows at now, or the zero
+// time when every post is allowed.
+func (s PostSort) Since(now time.Time) time.Time {
+	if d := windows[s.Window]; d != 0 {
+		return now.Add(-d)
+	}
+	return time.Time{}
+}
+
+func (s PostSort) String() string {
+	if s.Window == "" {
+		return s.Mode
+	}
+	return s.Mode + ":" + s.Window
+}
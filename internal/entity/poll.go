@@ -0,0 +1,33 @@
+package entity
+
+import "time"
+
+// Poll is the poll of a post. Users vote once, for one option or, in multiple choice
+// polls, for several. The voters of each option are listed unless the poll is
+// anonymous, and a poll with a close time takes no votes after it.
+type Poll struct {
+	ID          uint         `json:"id"`
+	PostID      uint         `json:"post_id"`
+	Question    string       `json:"question"`
+	Multiple    bool         `json:"multiple"`
+	Anonymous   bool         `json:"anonymous"`
+	ClosesAt    *time.Time   `json:"closes_at"`
+	Closed      bool         `json:"closed"`
+	TotalVoters uint         `json:"total_voters"`
+	Options     []PollOption `json:"options"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+type PollOption struct {
+	ID     uint     `json:"id"`
+	Text   string   `json:"text"`
+	Votes  uint     `json:"votes"`
+	Voters []string `json:"voters,omitempty"`
+}
+
+// PollVote is the ballot of UserID, with the options that the user chose.
+type PollVote struct {
+	PollID    uint   `json:"poll_id"`
+	UserID    uint   `json:"user_id"`
+	OptionIDs []uint `json:"option_ids"`
+}
@@ -1,15 +1,43 @@
 package entity
 
+import "time"
+
 type Post struct {
-	PostID   uint      `json:"post_id"`
-	UserID   uint      `json:"user_id"`
-	UserName string    `json:"username"`
-	Tags     []string  `json:"tags"`
-	Title    string    `json:"title"`
-	Data     string    `json:"data"`
-	Likes    uint      `json:"likes"`
-	Dislikes uint      `json:"dislikes"`
-	Comments []Comment `json:"comments"`
+	PostID            uint         `json:"post_id"`
+	UserID            uint         `json:"user_id"`
+	UserName          string       `json:"username"`
+	UserReputation    int          `json:"user_reputation"`
+	Tags              []string     `json:"tags"`
+	Title             string       `json:"title"`
+	Data              string       `json:"data"`
+	DataHTML          string       `json:"data_html"`
+	Likes             uint         `json:"likes"`
+	Dislikes          uint         `json:"dislikes"`
+	Locked            bool         `json:"locked"`
+	Pinned            bool         `json:"pinned"`
+	Status            string       `json:"status"`
+	PublishAt         *time.Time   `json:"publish_at"`
+	AcceptedCommentID uint         `json:"accepted_comment_id"`
+	CommentCount      uint         `json:"comment_count"`
+	Score             float64      `json:"-"`
+	EditedAt          *time.Time   `json:"edited_at"`
+	CreatedAt         time.Time    `json:"created_at"`
+	UpdatedAt         time.Time    `json:"updated_at"`
+	Comments          []Comment    `json:"comments"`
+	Attachments       []Attachment `json:"attachments"`
+	Poll              *Poll        `json:"poll"`
+}
+
+// The status of a post. Drafts and scheduled posts are only visible to their author
+// until they are published.
+const (
+	PostDraft     = "draft"
+	PostScheduled = "scheduled"
+	PostPublished = "published"
+)
+
+func IsValidPostStatus(status string) bool {
+	return status == PostDraft || status == PostScheduled || status == PostPublished
 }
 
 type Tag struct {
@@ -27,3 +55,39 @@ type PostVote struct {
 	PostID uint `json:"post_id"`
 	Vote   int  `json:"vote"`
 }
+
+// AcceptedAnswer marks a top-level comment as the answer of the post. A CommentID of 0
+// clears the accepted answer.
+type AcceptedAnswer struct {
+	UserID    uint `json:"user_id"`
+	PostID    uint `json:"post_id"`
+	CommentID uint `json:"comment_id"`
+}
+
+// PostRevision is the version of a post before the edit that EditorID made at
+// CreatedAt. Diff holds the changes of that edit.
+type PostRevision struct {
+	ID         uint      `json:"id"`
+	PostID     uint      `json:"post_id"`
+	EditorID   uint      `json:"editor_id"`
+	EditorName string    `json:"editor"`
+	Title      string    `json:"title"`
+	Data       string    `json:"data"`
+	Tags       []string  `json:"tags"`
+	CreatedAt  time.Time `json:"created_at"`
+	Diff       PostDiff  `json:"diff"`
+}
+
+// PostRevisionPage is one page of the revisions of a post, oldest first. NextCursor
+// is empty on the last page.
+type PostRevisionPage struct {
+	Revisions  []PostRevision `json:"revisions"`
+	NextCursor string         `json:"next_cursor"`
+}
+
+type PostDiff struct {
+	Title       string   `json:"title"`
+	Data        string   `json:"data"`
+	TagsAdded   []string `json:"tags_added"`
+	TagsRemoved []string `json:"tags_removed"`
+}
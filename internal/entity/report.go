@@ -0,0 +1,57 @@
+package entity
+
+import "time"
+
+const (
+	ReportSpam     = "spam"
+	ReportAbuse    = "abuse"
+	ReportOffTopic = "off_topic"
+	ReportOther    = "other"
+)
+
+const (
+	ReportOpen      = "open"
+	ReportDismissed = "dismissed"
+	ReportRemoved   = "removed"
+	ReportWarned    = "warned"
+	ReportBanned    = "banned"
+)
+
+// Report groups every open report about the same post, comment or user.
+type Report struct {
+	ID             uint      `json:"id"`
+	TargetType     string    `json:"target_type"`
+	TargetID       uint      `json:"target_id"`
+	AuthorID       uint      `json:"author_id"`
+	Status         string    `json:"status"`
+	Count          uint      `json:"count"`
+	Categories     []string  `json:"categories"`
+	CreatedAt      time.Time `json:"created_at"`
+	LastReportedAt time.Time `json:"last_reported_at"`
+}
+
+// ReportInput is a single user's report about a post, comment or user.
+type ReportInput struct {
+	ReporterID uint   `json:"-"`
+	TargetType string `json:"target_type"`
+	TargetID   uint   `json:"target_id"`
+	Category   string `json:"category"`
+	Comment    string `json:"comment"`
+}
+
+// ReportResolution is the moderator's decision on a report. Duration is only used by
+// the ban action; an empty duration bans permanently.
+type ReportResolution struct {
+	ReportID uint   `json:"report_id"`
+	Action   string `json:"action"`
+	Reason   string `json:"reason"`
+	Duration string `json:"duration"`
+}
+
+func IsValidReportCategory(category string) bool {
+	switch category {
+	case ReportSpam, ReportAbuse, ReportOffTopic, ReportOther:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+package entity
+
+// VoteWeights is the reputation that the author of a post or comment gains for a like
+// and for a dislike of it. Dislike is usually negative.
+type VoteWeights struct {
+	Like    int
+	Dislike int
+}
+
+// Of returns the weight of a vote, 1 is a like and 0 a dislike.
+func (w VoteWeights) Of(vote int) int {
+	if vote == 1 {
+		return w.Like
+	}
+	return w.Dislike
+}
+
+// ReputationWeights are the weights of the votes on posts and comments and the
+// reputation that the author of an accepted answer gains. Votes on own posts and
+// comments and accepted answers to own posts do not count.
+type ReputationWeights struct {
+	Post           VoteWeights
+	Comment        VoteWeights
+	AcceptedAnswer int
+}
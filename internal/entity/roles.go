@@ -1,11 +1,50 @@
 package entity
 
-var Roles = struct {
-	Guest      uint
-	User       uint
-	Authorized uint
-}{
-	Guest:      0,
-	User:       1,
-	Authorized: 3,
+type Role string
+
+const (
+	// RoleAnonymous marks routes that are only for clients that are not signed in.
+	// It is never stored for a user.
+	RoleAnonymous Role = "anonymous"
+	RoleGuest     Role = "guest"
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+type Permission uint
+
+const (
+	PermRead Permission = 1 << iota
+	// PermWrite allows creating, voting on and deleting own posts and comments.
+	PermWrite
+	// PermModerate allows deleting any post or comment.
+	PermModerate
+	// PermManageUsers allows changing roles of other users.
+	PermManageUsers
+)
+
+var rolePermissions = map[Role]Permission{
+	RoleGuest:     PermRead,
+	RoleUser:      PermRead | PermWrite,
+	RoleModerator: PermRead | PermWrite | PermModerate,
+	RoleAdmin:     PermRead | PermWrite | PermModerate | PermManageUsers,
+}
+
+// IsValid reports whether the role can be stored for a user.
+func (r Role) IsValid() bool {
+	switch r {
+	case RoleUser, RoleModerator, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+func (r Role) Can(p Permission) bool {
+	return rolePermissions[r]&p == p
+}
+
+// Includes reports whether r has every permission of other.
+func (r Role) Includes(other Role) bool {
+	return r.Can(rolePermissions[other])
 }
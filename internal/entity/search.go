@@ -0,0 +1,54 @@
+package entity
+
+import "time"
+
+const (
+	SearchPost    = "post"
+	SearchComment = "comment"
+)
+
+// SearchQuery is a full-text search request. Tag and Author narrow the results to the
+// posts with the tag (and their comments) and to the content written by the user.
+type SearchQuery struct {
+	Query  string
+	Tag    string
+	Author string
+	Cursor string
+	Limit  int
+}
+
+// SearchFilter is the search that the repository runs. Match is an FTS5 query.
+type SearchFilter struct {
+	Match  string
+	Tag    string
+	Author string
+	After  SearchCursor
+	Limit  int
+}
+
+// SearchResult is a post or a comment that matched a search. Snippet is HTML escaped
+// with the matched terms wrapped in <mark>.
+type SearchResult struct {
+	Type      string    `json:"type"`
+	PostID    uint      `json:"post_id"`
+	CommentID uint      `json:"comment_id,omitempty"`
+	UserID    uint      `json:"user_id"`
+	UserName  string    `json:"username"`
+	Title     string    `json:"title"`
+	Snippet   string    `json:"snippet"`
+	Rank      float64   `json:"rank"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SearchCursor is the position of the last result of a page. Results are ordered by
+// their BM25 rank, then by type and id.
+type SearchCursor struct {
+	Rank float64 `json:"rank"`
+	Type string  `json:"type"`
+	ID   uint    `json:"id"`
+}
+
+type SearchPage struct {
+	Results    []SearchResult `json:"results"`
+	NextCursor string         `json:"next_cursor"`
+}
@@ -1,6 +1,31 @@
 package entity
 
+import "time"
+
+// Session is one token of a sign-in; every refresh adds a row to the same family,
+// so the family identifies the device session.
 type Session struct {
-	UserID uint
-	Token  string
+	ID          uint      `json:"-"`
+	Family      string    `json:"id"`
+	UserID      uint      `json:"user_id"`
+	Token       string    `json:"-"`
+	RefreshHash string    `json:"-"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Rotated     bool      `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastSeen    time.Time `json:"last_seen"`
+	IP          string    `json:"ip"`
+	UserAgent   string    `json:"user_agent"`
+	Current     bool      `json:"current"`
+}
+
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Client describes where a request came from.
+type Client struct {
+	IP        string
+	UserAgent string
 }
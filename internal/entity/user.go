@@ -1,5 +1,7 @@
 package entity
 
+import "time"
+
 type User struct {
 	ID          uint   `json:"id"`
 	Email       string `json:"email"`
@@ -7,4 +9,8 @@ type User struct {
 	Password    string `json:"password"`
 	ConfirmPass string `json:"cfmpsw"`
 	HashPass    string
+	Role        Role      `json:"role"`
+	Reputation  int       `json:"reputation"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
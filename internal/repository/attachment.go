@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"forum/internal/entity"
+	"net/http"
+)
+
+type AttachmentRepository struct {
+	db *sql.DB
+}
+
+func newAttachmentRepository(db *sql.DB) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+func (r *AttachmentRepository) CreateAttachment(ctx context.Context, input entity.Attachment) (uint, int, error) {
+	query := `
+	INSERT INTO attachments(post_id, user_id, blob_key, thumbnail_key, file_name, mime, size, width, height)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9);
+	`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	res, err := prep.ExecContext(ctx, input.PostID, input.UserID, input.BlobKey, input.ThumbnailKey, input.FileName, input.MIME, input.Size, input.Width, input.Height)
+	if err != nil {
+		return 0, http.StatusBadRequest, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, http.StatusInternalServerError, err
+	}
+	return uint(id), http.StatusOK, nil
+}
+
+const attachmentColumns = `
+		id,
+		post_id,
+		user_id,
+		blob_key,
+		thumbnail_key,
+		file_name,
+		mime,
+		size,
+		width,
+		height,
+		created_at`
+
+func scanAttachment(row interface{ Scan(...any) error }) (entity.Attachment, error) {
+	var a entity.Attachment
+	err := row.Scan(&a.ID, &a.PostID, &a.UserID, &a.BlobKey, &a.ThumbnailKey, &a.FileName, &a.MIME, &a.Size, &a.Width, &a.Height, &a.CreatedAt)
+	a.HasThumbnail = a.ThumbnailKey != ""
+	return a, err
+}
+
+func (r *AttachmentRepository) GetAttachmentByID(ctx context.Context, id uint) (entity.Attachment, int, error) {
+	query := `SELECT` + attachmentColumns + ` FROM attachments WHERE id = $1;`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return entity.Attachment{}, http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	attachment, err := scanAttachment(prep.QueryRowContext(ctx, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return attachment, http.StatusNotFound, err
+		}
+		return attachment, http.StatusInternalServerError, err
+	}
+	return attachment, http.StatusOK, nil
+}
+
+func (r *AttachmentRepository) GetAttachmentsByPostID(ctx context.Context, postID uint) ([]entity.Attachment, int, error) {
+	query := `SELECT` + attachmentColumns + ` FROM attachments WHERE post_id = $1 ORDER BY id;`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	rows, err := prep.QueryContext(ctx, postID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	defer rows.Close()
+	attachments := []entity.Attachment{}
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		attachments = append(attachments, attachment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return attachments, http.StatusOK, nil
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"forum/internal/entity"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type AuditRepository struct {
+	db *sql.DB
+}
+
+func newAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+func (r *AuditRepository) CreateEntry(ctx context.Context, entry entity.AuditEntry) error {
+	query := `INSERT INTO audit_log(actor_id, action, target_type, target_id, ip, detail) VALUES($1, $2, $3, $4, $5, $6);`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer prep.Close()
+	if _, err := prep.ExecContext(ctx, nullID(entry.ActorID), entry.Action, entry.TargetType, nullID(entry.TargetID), entry.IP, entry.Detail); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *AuditRepository) GetEntries(ctx context.Context, filter entity.AuditFilter) ([]entity.AuditEntry, int, error) {
+	conditions := []string{}
+	args := []interface{}{}
+	if filter.ActorID != 0 {
+		args = append(args, filter.ActorID)
+		conditions = append(conditions, "a.actor_id = ?")
+	}
+	if filter.Action != "" {
+		args = append(args, filter.Action)
+		conditions = append(conditions, "a.action = ?")
+	}
+	if !filter.From.IsZero() {
+		args = append(args, filter.From.UTC().Format(sqliteTime))
+		conditions = append(conditions, "a.created_at >= ?")
+	}
+	if !filter.To.IsZero() {
+		args = append(args, filter.To.UTC().Format(sqliteTime))
+		conditions = append(conditions, "a.created_at < ?")
+	}
+	query := `
+	SELECT
+		a.id,
+		COALESCE(a.actor_id, 0),
+		COALESCE(u.username, ''),
+		a.action,
+		a.target_type,
+		COALESCE(a.target_id, 0),
+		a.ip,
+		a.detail,
+		a.created_at
+	FROM
+		audit_log a
+		LEFT JOIN users u ON u.id = a.actor_id
+	`
+	if len(conditions) > 0 {
+		query += "WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY a.id DESC LIMIT ?;"
+	args = append(args, filter.Limit)
+
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	rows, err := prep.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	defer rows.Close()
+	entries := []entity.AuditEntry{}
+	for rows.Next() {
+		entry := entity.AuditEntry{}
+		if err := rows.Scan(&entry.ID, &entry.ActorID, &entry.ActorName, &entry.Action, &entry.TargetType, &entry.TargetID, &entry.IP, &entry.Detail, &entry.CreatedAt); err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, http.StatusOK, nil
+}
+
+// sqliteTime is the layout of CURRENT_TIMESTAMP, used to compare with DATETIME columns.
+const sqliteTime = "2006-01-02 15:04:05"
+
+// nullID stores 0 ids as NULL.
+func nullID(id uint) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(id), Valid: id != 0}
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// nullTime stores nil times as NULL and other times in the layout of CURRENT_TIMESTAMP.
+func nullTime(t *time.Time) sql.NullString {
+	if t == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: t.UTC().Format(sqliteTime), Valid: true}
+}
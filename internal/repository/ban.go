@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"forum/internal/entity"
+	"net/http"
+)
+
+type BanRepository struct {
+	db *sql.DB
+}
+
+func newBanRepository(db *sql.DB) *BanRepository {
+	return &BanRepository{db: db}
+}
+
+func (r *BanRepository) CreateBan(ctx context.Context, ban entity.Ban) (uint, int, error) {
+	query := `INSERT INTO bans(user_id, admin_id, reason, expires_at) VALUES($1, $2, $3, $4);`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	res, err := prep.ExecContext(ctx, ban.UserID, nullID(ban.AdminID), ban.Reason, nullTime(ban.ExpiresAt))
+	if err != nil {
+		return 0, http.StatusBadRequest, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, http.StatusInternalServerError, err
+	}
+	return uint(id), http.StatusOK, nil
+}
+
+// GetBans returns the bans that were not lifted, expired ones included. A userID of 0
+// returns the bans of all users.
+func (r *BanRepository) GetBans(ctx context.Context, userID uint) ([]entity.Ban, int, error) {
+	query := `
+	SELECT
+		b.id,
+		b.user_id,
+		u.username,
+		COALESCE(b.admin_id, 0),
+		COALESCE(a.username, ''),
+		b.reason,
+		b.expires_at,
+		b.created_at
+	FROM
+		bans b
+		JOIN users u ON u.id = b.user_id
+		LEFT JOIN users a ON a.id = b.admin_id
+	WHERE
+		b.lifted_at IS NULL AND ($1 = 0 OR b.user_id = $1)
+	ORDER BY b.id DESC;
+	`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	rows, err := prep.QueryContext(ctx, userID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	defer rows.Close()
+	bans := []entity.Ban{}
+	for rows.Next() {
+		ban := entity.Ban{}
+		var expiresAt sql.NullTime
+		if err := rows.Scan(&ban.ID, &ban.UserID, &ban.UserName, &ban.AdminID, &ban.AdminName, &ban.Reason, &expiresAt, &ban.CreatedAt); err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		ban.ExpiresAt = timePtr(expiresAt)
+		bans = append(bans, ban)
+	}
+	return bans, http.StatusOK, nil
+}
+
+func (r *BanRepository) LiftBan(ctx context.Context, banID uint, adminID uint) (int, error) {
+	query := `UPDATE bans SET lifted_at = CURRENT_TIMESTAMP, lifted_by = $1 WHERE id = $2 AND lifted_at IS NULL;`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	res, err := prep.ExecContext(ctx, nullID(adminID), banID)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return http.StatusInternalServerError, err
+	} else if n == 0 {
+		return http.StatusNotFound, sql.ErrNoRows
+	}
+	return http.StatusOK, nil
+}
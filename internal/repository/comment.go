@@ -16,60 +16,267 @@ func newCommentRepository(db *sql.DB) *CommentRepository {
 }
 
 func (r *CommentRepository) CreateComment(ctx context.Context, input entity.Comment) (int, error) {
-	query := `INSERT INTO comment(user_id, post_id, data) VALUES($1, $2, $3)`
+	query := `INSERT INTO comment(user_id, post_id, parent_id, data, created_at, updated_at) VALUES($1, $2, $3, $4, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`
 	prep, err := r.db.PrepareContext(ctx, query)
 	if err != nil {
 		return http.StatusInternalServerError, err
 	}
 	defer prep.Close()
-	if _, err := prep.ExecContext(ctx, input.UserID, input.PostID, input.Data); err != nil {
+	if _, err := prep.ExecContext(ctx, input.UserID, input.PostID, nullID(input.ParentID), input.Data); err != nil {
 		return http.StatusBadRequest, err
 	}
 	return http.StatusOK, nil
 }
 
+// DeleteComment deletes the comment of the user, see deleteComment.
 func (r *CommentRepository) DeleteComment(ctx context.Context, commentID uint, userID uint) (int, error) {
-	query := `DELETE FROM comment WHERE id = $1 AND user_id = $2`
-	prep, err := r.db.PrepareContext(ctx, query)
+	return r.deleteComment(ctx, commentID, userID)
+}
+
+// DeleteAnyComment deletes the comment of any user, see deleteComment.
+func (r *CommentRepository) DeleteAnyComment(ctx context.Context, commentID uint) (int, error) {
+	return r.deleteComment(ctx, commentID, 0)
+}
+
+// deleteComment deletes the comment when authorID is 0 or its author. A comment with
+// replies becomes a tombstone instead: its text, revisions and accepted answer are
+// removed but the row stays, so that the replies of other users and their votes are
+// kept. Tombstones that have no replies left are deleted with the last one.
+func (r *CommentRepository) deleteComment(ctx context.Context, commentID uint, authorID uint) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return http.StatusInternalServerError, err
 	}
-	defer prep.Close()
-	if _, err := prep.ExecContext(ctx, commentID, userID); err != nil {
-		return http.StatusBadRequest, err
+	defer tx.Rollback()
+	query := `
+	SELECT parent_id, EXISTS (SELECT 1 FROM comment reply WHERE reply.parent_id = comment.id)
+	FROM comment
+	WHERE id = $1 AND deleted = 0 AND ($2 = 0 OR user_id = $2);
+	`
+	var parentID sql.NullInt64
+	var replies bool
+	if err := tx.QueryRowContext(ctx, query, commentID, authorID).Scan(&parentID, &replies); err != nil {
+		if err == sql.ErrNoRows {
+			return http.StatusNotFound, err
+		}
+		return http.StatusInternalServerError, err
+	}
+	if replies {
+		query = `UPDATE comment SET data = '', deleted = 1, edited_at = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = $1;`
+		if _, err := tx.ExecContext(ctx, query, commentID); err != nil {
+			return http.StatusInternalServerError, err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM comment_revision WHERE comment_id = $1;`, commentID); err != nil {
+			return http.StatusInternalServerError, err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE post SET accepted_comment_id = NULL WHERE accepted_comment_id = $1;`, commentID); err != nil {
+			return http.StatusInternalServerError, err
+		}
+	} else {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM comment WHERE id = $1;`, commentID); err != nil {
+			return http.StatusInternalServerError, err
+		}
+		query = `
+		SELECT parent_id FROM comment
+		WHERE id = $1 AND deleted = 1 AND NOT EXISTS (SELECT 1 FROM comment reply WHERE reply.parent_id = comment.id);
+		`
+		for parentID.Valid {
+			tombstoneID := parentID.Int64
+			if err := tx.QueryRowContext(ctx, query, tombstoneID).Scan(&parentID); err != nil {
+				if err == sql.ErrNoRows {
+					break
+				}
+				return http.StatusInternalServerError, err
+			}
+			if _, err := tx.ExecContext(ctx, `DELETE FROM comment WHERE id = $1;`, tombstoneID); err != nil {
+				return http.StatusInternalServerError, err
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return http.StatusInternalServerError, err
 	}
 	return http.StatusOK, nil
 }
 
-func (r *CommentRepository) UpsertCommentVote(ctx context.Context, input entity.CommentVote) (int, error) {
+// GetCommentVote returns the vote of the user on the comment, 1 for a like and 0 for a dislike.
+func (r *CommentRepository) GetCommentVote(ctx context.Context, commentID uint, userID uint) (int, int, error) {
 	query := "SELECT vote FROM comment_vote WHERE user_id = $1 and comment_id = $2;"
 	prep, err := r.db.PrepareContext(ctx, query)
 	if err != nil {
-		return http.StatusInternalServerError, err
+		return 0, http.StatusInternalServerError, err
 	}
 	defer prep.Close()
 	var vote int
+	if err := prep.QueryRowContext(ctx, userID, commentID).Scan(&vote); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, http.StatusNotFound, err
+		}
+		return 0, http.StatusInternalServerError, err
+	}
+	return vote, http.StatusOK, nil
+}
+
+// UpsertCommentVote adds the vote of the user, changes it, or removes it when the user
+// votes the same way again. The reputation of the comment author changes by the weight of
+// the new vote minus the weight of the old one, votes on own comments do not count.
+func (r *CommentRepository) UpsertCommentVote(ctx context.Context, input entity.CommentVote, weights entity.VoteWeights) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	defer tx.Rollback()
+	query := "SELECT vote FROM comment_vote WHERE user_id = $1 and comment_id = $2;"
+	prep, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	var vote, reputation int
 	if err := prep.QueryRowContext(ctx, input.UserID, input.CommentID).Scan(&vote); err != nil {
 		if err == sql.ErrNoRows {
-			query = "INSERT INTO comment_vote(user_id, comment_id, vote) VALUES($1, $2, $3);"
-			if _, err = r.db.ExecContext(ctx, query, input.UserID, input.CommentID, input.Vote); err != nil {
+			query = "INSERT INTO comment_vote(user_id, comment_id, vote, created_at, updated_at) VALUES($1, $2, $3, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP);"
+			if _, err = tx.ExecContext(ctx, query, input.UserID, input.CommentID, input.Vote); err != nil {
 				return http.StatusBadRequest, err
 			}
+			reputation = weights.Of(input.Vote)
 		} else {
 			return http.StatusInternalServerError, err
 		}
 	} else {
 		if vote == input.Vote {
 			query = "DELETE FROM comment_vote WHERE user_id = $1 and comment_id = $2;"
-			if _, err := r.db.ExecContext(ctx, query, input.UserID, input.CommentID); err != nil {
+			if _, err := tx.ExecContext(ctx, query, input.UserID, input.CommentID); err != nil {
 				return http.StatusInternalServerError, err
 			}
+			reputation = -weights.Of(vote)
 		} else {
-			query = "UPDATE comment_vote SET vote = $1 WHERE user_id = $2 and comment_id = $3;"
-			if _, err := r.db.ExecContext(ctx, query, input.Vote, input.UserID, input.CommentID); err != nil {
+			query = "UPDATE comment_vote SET vote = $1, updated_at = CURRENT_TIMESTAMP WHERE user_id = $2 and comment_id = $3;"
+			if _, err := tx.ExecContext(ctx, query, input.Vote, input.UserID, input.CommentID); err != nil {
 				return http.StatusInternalServerError, err
 			}
+			reputation = weights.Of(input.Vote) - weights.Of(vote)
 		}
 	}
+	query = "UPDATE users SET reputation = reputation + $1 WHERE id = (SELECT user_id FROM comment WHERE id = $2) AND id != $3;"
+	if _, err := tx.ExecContext(ctx, query, reputation, input.CommentID, input.UserID); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if err := tx.Commit(); err != nil {
+		return http.StatusInternalServerError, err
+	}
 	return http.StatusOK, nil
 }
+
+func (r *CommentRepository) GetCommentByID(ctx context.Context, commentID uint) (entity.Comment, int, error) {
+	comment := entity.Comment{}
+	query := `SELECT id, user_id, post_id, COALESCE(parent_id, 0), data, edited_at, created_at, updated_at FROM comment WHERE id = $1 AND deleted = 0;`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return comment, http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	var editedAt sql.NullTime
+	if err := prep.QueryRowContext(ctx, commentID).Scan(&comment.CommentID, &comment.UserID, &comment.PostID, &comment.ParentID, &comment.Data, &editedAt, &comment.CreatedAt, &comment.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return comment, http.StatusNotFound, err
+		}
+		return comment, http.StatusInternalServerError, err
+	}
+	comment.EditedAt = timePtr(editedAt)
+	return comment, http.StatusOK, nil
+}
+
+// UpdateComment saves the current text of the comment as a revision made by editorID
+// and replaces it with data in one transaction.
+func (r *CommentRepository) UpdateComment(ctx context.Context, commentID uint, editorID uint, data string) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	defer tx.Rollback()
+	query := `INSERT INTO comment_revision(comment_id, editor_id, data) SELECT id, $1, data FROM comment WHERE id = $2;`
+	res, err := tx.ExecContext(ctx, query, nullID(editorID), commentID)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return http.StatusInternalServerError, err
+	} else if n == 0 {
+		return http.StatusNotFound, sql.ErrNoRows
+	}
+	query = `UPDATE comment SET data = $1, edited_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = $2;`
+	if _, err := tx.ExecContext(ctx, query, data, commentID); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if err := tx.Commit(); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// GetCommentRevisions returns up to limit previous versions of the comment after the
+// revision afterID, oldest first.
+func (r *CommentRepository) GetCommentRevisions(ctx context.Context, commentID uint, afterID uint, limit int) ([]entity.CommentRevision, int, error) {
+	query := `
+	SELECT
+		cr.id,
+		cr.comment_id,
+		COALESCE(cr.editor_id, 0),
+		COALESCE(u.username, ''),
+		cr.data,
+		cr.created_at
+	FROM
+		comment_revision cr
+		LEFT JOIN users u ON u.id = cr.editor_id
+	WHERE
+		cr.comment_id = $1
+		AND cr.id > $2
+	ORDER BY cr.id
+	LIMIT $3;
+	`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	rows, err := prep.QueryContext(ctx, commentID, afterID, limit)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	defer rows.Close()
+	revisions := []entity.CommentRevision{}
+	for rows.Next() {
+		revision := entity.CommentRevision{}
+		if err := rows.Scan(&revision.ID, &revision.CommentID, &revision.EditorID, &revision.EditorName, &revision.Data, &revision.CreatedAt); err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, http.StatusOK, nil
+}
+
+// GetCommentDepth returns how many ancestors the comment has.
+func (r *CommentRepository) GetCommentDepth(ctx context.Context, commentID uint) (int, int, error) {
+	query := `
+	WITH RECURSIVE ancestors(id, parent_id) AS (
+		SELECT id, parent_id FROM comment WHERE id = $1
+		UNION ALL
+		SELECT c.id, c.parent_id FROM comment c INNER JOIN ancestors a ON c.id = a.parent_id
+	)
+	SELECT COUNT(*) - 1 FROM ancestors;
+	`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	var depth int
+	if err := prep.QueryRowContext(ctx, commentID).Scan(&depth); err != nil {
+		return 0, http.StatusInternalServerError, err
+	}
+	if depth < 0 {
+		return 0, http.StatusNotFound, sql.ErrNoRows
+	}
+	return depth, http.StatusOK, nil
+}
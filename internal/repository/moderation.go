@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"forum/internal/entity"
+	"net/http"
+)
+
+type ModerationRepository struct {
+	db *sql.DB
+}
+
+func newModerationRepository(db *sql.DB) *ModerationRepository {
+	return &ModerationRepository{db: db}
+}
+
+func (r *ModerationRepository) CreateAction(ctx context.Context, action entity.ModerationAction) (int, error) {
+	query := `INSERT INTO moderation_log(moderator_id, action, target_type, target_id, reason) VALUES($1, $2, $3, $4, $5);`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	if _, err := prep.ExecContext(ctx, action.ModeratorID, action.Action, action.TargetType, action.TargetID, action.Reason); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (r *ModerationRepository) GetActions(ctx context.Context) ([]entity.ModerationAction, int, error) {
+	query := `
+	SELECT
+		m.id,
+		COALESCE(m.moderator_id, 0),
+		COALESCE(u.username, ''),
+		m.action,
+		m.target_type,
+		m.target_id,
+		m.reason,
+		m.created_at
+	FROM
+		moderation_log m
+		LEFT JOIN users u ON u.id = m.moderator_id
+	ORDER BY m.id DESC;
+	`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	rows, err := prep.QueryContext(ctx)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	defer rows.Close()
+	actions := []entity.ModerationAction{}
+	for rows.Next() {
+		action := entity.ModerationAction{}
+		if err := rows.Scan(&action.ID, &action.ModeratorID, &action.ModeratorName, &action.Action, &action.TargetType, &action.TargetID, &action.Reason, &action.CreatedAt); err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		actions = append(actions, action)
+	}
+	return actions, http.StatusOK, nil
+}
@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"forum/internal/entity"
+	"net/http"
+)
+
+type PollRepository struct {
+	db *sql.DB
+}
+
+func newPollRepository(db *sql.DB) *PollRepository {
+	return &PollRepository{db: db}
+}
+
+// CreatePoll creates the poll of a post with its options in one transaction.
+func (r *PollRepository) CreatePoll(ctx context.Context, input entity.Poll) (uint, int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, http.StatusInternalServerError, err
+	}
+	defer tx.Rollback()
+	query := `INSERT INTO poll(post_id, question, multiple, anonymous, closes_at) VALUES($1, $2, $3, $4, $5) RETURNING id;`
+	var id uint
+	if err := tx.QueryRowContext(ctx, query, input.PostID, input.Question, input.Multiple, input.Anonymous, nullTime(input.ClosesAt)).Scan(&id); err != nil {
+		return 0, http.StatusBadRequest, err
+	}
+	for i, option := range input.Options {
+		query = `INSERT INTO poll_option(poll_id, position, text) VALUES($1, $2, $3);`
+		if _, err := tx.ExecContext(ctx, query, id, i, option.Text); err != nil {
+			return 0, http.StatusBadRequest, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, http.StatusInternalServerError, err
+	}
+	return id, http.StatusOK, nil
+}
+
+// DeletePollByPostID removes the poll of the post with its options and votes.
+func (r *PollRepository) DeletePollByPostID(ctx context.Context, postID uint) (int, error) {
+	query := `DELETE FROM poll WHERE post_id = $1;`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	if _, err := prep.ExecContext(ctx, postID); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (r *PollRepository) GetPollByID(ctx context.Context, pollID uint) (entity.Poll, int, error) {
+	return r.getPoll(ctx, `p.id = $1`, pollID)
+}
+
+func (r *PollRepository) GetPollByPostID(ctx context.Context, postID uint) (entity.Poll, int, error) {
+	return r.getPoll(ctx, `p.post_id = $1`, postID)
+}
+
+// getPoll returns the poll that matches the condition with the vote counts of its
+// options, and their voters unless the poll is anonymous.
+func (r *PollRepository) getPoll(ctx context.Context, where string, arg any) (entity.Poll, int, error) {
+	query := `
+	SELECT
+		p.id,
+		p.post_id,
+		p.question,
+		p.multiple,
+		p.anonymous,
+		p.closes_at,
+		p.created_at,
+		(SELECT COUNT(DISTINCT v.user_id) FROM poll_vote v WHERE v.poll_id = p.id)
+	FROM
+		poll p
+	WHERE
+		` + where + `;
+	`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return entity.Poll{}, http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	var poll entity.Poll
+	var closesAt sql.NullTime
+	if err := prep.QueryRowContext(ctx, arg).Scan(&poll.ID, &poll.PostID, &poll.Question, &poll.Multiple, &poll.Anonymous, &closesAt, &poll.CreatedAt, &poll.TotalVoters); err != nil {
+		if err == sql.ErrNoRows {
+			return entity.Poll{}, http.StatusNotFound, err
+		}
+		return entity.Poll{}, http.StatusInternalServerError, err
+	}
+	poll.ClosesAt = timePtr(closesAt)
+	if poll.Options, err = r.getOptions(ctx, poll.ID); err != nil {
+		return entity.Poll{}, http.StatusInternalServerError, err
+	}
+	if !poll.Anonymous {
+		if err := r.setVoters(ctx, poll.ID, poll.Options); err != nil {
+			return entity.Poll{}, http.StatusInternalServerError, err
+		}
+	}
+	return poll, http.StatusOK, nil
+}
+
+func (r *PollRepository) getOptions(ctx context.Context, pollID uint) ([]entity.PollOption, error) {
+	query := `
+	SELECT
+		o.id,
+		o.text,
+		COUNT(v.user_id)
+	FROM
+		poll_option o
+		LEFT JOIN poll_vote v ON v.option_id = o.id
+	WHERE
+		o.poll_id = $1
+	GROUP BY o.id
+	ORDER BY o.position;
+	`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer prep.Close()
+	rows, err := prep.QueryContext(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	options := []entity.PollOption{}
+	for rows.Next() {
+		var option entity.PollOption
+		if err := rows.Scan(&option.ID, &option.Text, &option.Votes); err != nil {
+			return nil, err
+		}
+		options = append(options, option)
+	}
+	return options, rows.Err()
+}
+
+// setVoters sets the names of the users that voted for each option, in the order
+// of their votes.
+func (r *PollRepository) setVoters(ctx context.Context, pollID uint, options []entity.PollOption) error {
+	query := `
+	SELECT
+		v.option_id,
+		u.username
+	FROM
+		poll_vote v
+		INNER JOIN users u ON u.id = v.user_id
+	WHERE
+		v.poll_id = $1
+	ORDER BY v.created_at, v.user_id;
+	`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer prep.Close()
+	rows, err := prep.QueryContext(ctx, pollID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	index := make(map[uint]int, len(options))
+	for i, option := range options {
+		index[option.ID] = i
+	}
+	for rows.Next() {
+		var optionID uint
+		var username string
+		if err := rows.Scan(&optionID, &username); err != nil {
+			return err
+		}
+		if i, ok := index[optionID]; ok {
+			options[i].Voters = append(options[i].Voters, username)
+		}
+	}
+	return rows.Err()
+}
+
+// CreatePollVote saves the ballot of the user. The first option is only inserted when
+// the user has not voted in the poll yet, so a user can vote once even when two
+// ballots are sent at the same time.
+func (r *PollRepository) CreatePollVote(ctx context.Context, input entity.PollVote) (int, error) {
+	if len(input.OptionIDs) == 0 {
+		return http.StatusBadRequest, errors.New("no options")
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	defer tx.Rollback()
+	query := `
+	INSERT INTO poll_vote(poll_id, option_id, user_id)
+	SELECT $1, $2, $3
+	WHERE NOT EXISTS (SELECT 1 FROM poll_vote WHERE poll_id = $1 AND user_id = $3);
+	`
+	res, err := tx.ExecContext(ctx, query, input.PollID, input.OptionIDs[0], input.UserID)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return http.StatusInternalServerError, err
+	} else if n == 0 {
+		return http.StatusConflict, errors.New("already voted")
+	}
+	for _, optionID := range input.OptionIDs[1:] {
+		query = `INSERT INTO poll_vote(poll_id, option_id, user_id) VALUES($1, $2, $3);`
+		if _, err := tx.ExecContext(ctx, query, input.PollID, optionID, input.UserID); err != nil {
+			return http.StatusBadRequest, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
@@ -3,9 +3,11 @@ package repository
 import (
 	"context"
 	"database/sql"
-	"fmt"
+	"encoding/json"
 	"forum/internal/entity"
 	"net/http"
+	"strings"
+	"time"
 )
 
 type PostRepository struct {
@@ -16,126 +18,203 @@ func newPostRepository(db *sql.DB) *PostRepository {
 	return &PostRepository{db: db}
 }
 
-func (r *PostRepository) GetAllByTag(ctx context.Context, tagName string) ([]entity.Post, int, error) {
-	query := `
-	SELECT
+const postListColumns = `
 		p.id,
 		p.user_id,
 		p.title,
 		p.data,
-		u.username
+		p.locked,
+		p.pinned,
+		p.status,
+		p.publish_at,
+		COALESCE(p.accepted_comment_id, 0),
+		p.edited_at,
+		p.created_at,
+		p.updated_at,
+		u.username,
+		u.reputation,
+		COALESCE(v.likes, 0),
+		COALESCE(v.dislikes, 0),
+		COALESCE(c.comments, 0)`
+
+// postListJoins joins the vote and comment counts that postListColumns selects.
+const postListJoins = `
+		LEFT JOIN (
+			SELECT post_id, SUM(vote = 1) AS likes, SUM(vote = 0) AS dislikes FROM post_vote GROUP BY post_id
+		) v ON v.post_id = p.id
+		LEFT JOIN (
+			SELECT post_id, COUNT(*) AS comments FROM comment WHERE deleted = 0 GROUP BY post_id
+		) c ON c.post_id = p.id`
+
+// postScores are the score expressions of the feed sorts. The new sort orders by the
+// creation time, which is the publication time of published drafts. The hot score
+// divides the vote balance by the squared age in hours at the reference time ?3, so
+// new posts with a few votes rank above old posts with many. SQLite numbers $N
+// parameters in the order they first appear, so the feed query uses ?N ones.
+var postScores = map[string]string{
+	entity.SortNew: `unixepoch(p.created_at)`,
+	entity.SortTop: `COALESCE(v.likes, 0) - COALESCE(v.dislikes, 0)`,
+	entity.SortHot: `(COALESCE(v.likes, 0) - COALESCE(v.dislikes, 0) + 1) /
+		((MAX(?3 - unixepoch(p.created_at), 0) / 3600.0 + 2) * (MAX(?3 - unixepoch(p.created_at), 0) / 3600.0 + 2))`,
+	entity.SortActive: `COALESCE(c.comments, 0)`,
+}
+
+// GetAllByTag returns up to limit published posts of the tag that come after the
+// cursor. Pinned posts come first, then the posts are ordered by the score of the sort
+// and newest first. The cursor time is the reference time of the hot score and the top
+// window, posts created after it are left out so that later pages do not shift.
+// Unanswered leaves out the posts that have an accepted answer.
+func (r *PostRepository) GetAllByTag(ctx context.Context, tagName string, sort entity.PostSort, unanswered bool, after entity.PostCursor, limit int) ([]entity.Post, int, error) {
+	query := `
+	SELECT` + postListColumns + `,
+		` + postScores[sort.Mode] + ` AS score
 	FROM
 		post p
 		INNER JOIN tag_and_post tp ON p.id = tp.post_id
 		INNER JOIN tags t ON tp.tag_id = t.id
-		INNER JOIN users u ON u.id = p.user_id
-	WHERE t.name = $1;
+		INNER JOIN users u ON u.id = p.user_id` + postListJoins + `
+	WHERE
+		t.name = ?1
+		AND p.status = 'published'
+		AND (?8 = 0 OR p.accepted_comment_id IS NULL)
+		AND unixepoch(p.created_at) BETWEEN ?2 AND ?3
+		AND (?4 = 0 OR p.pinned < ?5 OR (p.pinned = ?5 AND (score < ?6 OR (score = ?6 AND p.id < ?4))))
+	ORDER BY p.pinned DESC, score DESC, p.id DESC
+	LIMIT ?7;
 	`
-	prep, err := r.db.PrepareContext(ctx, query)
-	if err != nil {
-		return nil, http.StatusInternalServerError, err
-	}
-	defer prep.Close()
-	posts := []entity.Post{}
-	rows, err := prep.QueryContext(ctx, tagName)
-	if err != nil {
-		return nil, http.StatusBadRequest, err
+	var since int64
+	if t := sort.Since(time.Unix(after.Time, 0)); !t.IsZero() {
+		since = t.Unix()
 	}
-	for rows.Next() {
-		post := entity.Post{}
-		if err := rows.Scan(&post.PostID, &post.UserID, &post.Title, &post.Data, &post.UserName); err != nil {
-			return nil, http.StatusInternalServerError, err
-		}
-		tags, status, err := r.getTagsByPostID(ctx, post.PostID)
-		if err != nil {
-			return nil, status, err
-		}
-		post.Tags = tags
-		posts = append(posts, post)
-	}
-	return posts, http.StatusOK, nil
+	return r.getPostList(ctx, query, tagName, since, after.Time, after.ID, after.Pinned, after.Score, limit, unanswered)
 }
 
-func (r *PostRepository) GetAllByUserID(ctx context.Context, userID uint) ([]entity.Post, int, error) {
-	posts := []entity.Post{}
+// GetAllByUserID returns up to limit published posts of the user, newest first, that
+// come after the cursor.
+func (r *PostRepository) GetAllByUserID(ctx context.Context, userID uint, after entity.PostCursor, limit int) ([]entity.Post, int, error) {
 	query := `
-	SELECT
-		p.id,
-		p.user_id,
-		p.title,
-		p.data,
-		u.username
+	SELECT` + postListColumns + `,
+		0 AS score
 	FROM
 		post p
-		INNER JOIN users u ON u.id = p.user_id
-	WHERE p.user_id = $1;
+		INNER JOIN users u ON u.id = p.user_id` + postListJoins + `
+	WHERE
+		p.user_id = $1 AND p.status = 'published' AND ($2 = 0 OR p.id < $2)
+	ORDER BY p.id DESC
+	LIMIT $3;
+	`
+	return r.getPostList(ctx, query, userID, after.ID, limit)
+}
+
+// GetDraftsByUserID returns up to limit drafts and scheduled posts of the user, newest
+// first, that come after the cursor.
+func (r *PostRepository) GetDraftsByUserID(ctx context.Context, userID uint, after entity.PostCursor, limit int) ([]entity.Post, int, error) {
+	query := `
+	SELECT` + postListColumns + `,
+		0 AS score
+	FROM
+		post p
+		INNER JOIN users u ON u.id = p.user_id` + postListJoins + `
+	WHERE
+		p.user_id = $1 AND p.status != 'published' AND ($2 = 0 OR p.id < $2)
+	ORDER BY p.id DESC
+	LIMIT $3;
 	`
+	return r.getPostList(ctx, query, userID, after.ID, limit)
+}
+
+// GetAllLikedPostsByUserID returns up to limit posts that the user liked (or disliked),
+// newest first, that come after the cursor.
+func (r *PostRepository) GetAllLikedPostsByUserID(ctx context.Context, userID uint, islike bool, after entity.PostCursor, limit int) ([]entity.Post, int, error) {
+	query := `
+	SELECT` + postListColumns + `,
+		0 AS score
+	FROM
+		post p
+		INNER JOIN users u on p.user_id = u.id
+		INNER JOIN post_vote pv ON p.id = pv.post_id` + postListJoins + `
+	WHERE
+		pv.user_id = $1 AND pv.vote = $2 AND p.status = 'published' AND ($3 = 0 OR p.id < $3)
+	ORDER BY p.id DESC
+	LIMIT $4;
+	`
+	return r.getPostList(ctx, query, userID, islike, after.ID, limit)
+}
+
+// getPostList runs a query that selects postListColumns and a score, and loads the tags of all
+// returned posts with a single query.
+func (r *PostRepository) getPostList(ctx context.Context, query string, args ...any) ([]entity.Post, int, error) {
 	prep, err := r.db.PrepareContext(ctx, query)
 	if err != nil {
 		return nil, http.StatusInternalServerError, err
 	}
 	defer prep.Close()
-	rows, err := prep.QueryContext(ctx, userID)
+	rows, err := prep.QueryContext(ctx, args...)
 	if err != nil {
 		return nil, http.StatusBadRequest, err
 	}
+	defer rows.Close()
+	posts := []entity.Post{}
 	for rows.Next() {
 		post := entity.Post{}
-		if err := rows.Scan(&post.PostID, &post.UserID, &post.Title, &post.Data, &post.UserName); err != nil {
+		var publishAt, editedAt sql.NullTime
+		if err := rows.Scan(&post.PostID, &post.UserID, &post.Title, &post.Data, &post.Locked, &post.Pinned, &post.Status, &publishAt, &post.AcceptedCommentID, &editedAt, &post.CreatedAt, &post.UpdatedAt, &post.UserName, &post.UserReputation, &post.Likes, &post.Dislikes, &post.CommentCount, &post.Score); err != nil {
 			return nil, http.StatusInternalServerError, err
 		}
-		tags, status, err := r.getTagsByPostID(ctx, post.PostID)
-		if err != nil {
-			return nil, status, err
-		}
-		post.Tags = tags
+		post.PublishAt = timePtr(publishAt)
+		post.EditedAt = timePtr(editedAt)
+		post.Tags = []string{}
 		posts = append(posts, post)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	if status, err := r.setTags(ctx, posts); err != nil {
+		return nil, status, err
+	}
 	return posts, http.StatusOK, nil
 }
 
-func (r *PostRepository) GetAllLikedPostsByUserID(ctx context.Context, userID uint, islike bool) ([]entity.Post, int, error) {
-	posts := []entity.Post{}
+func (r *PostRepository) setTags(ctx context.Context, posts []entity.Post) (int, error) {
+	if len(posts) == 0 {
+		return http.StatusOK, nil
+	}
+	index := make(map[uint]int, len(posts))
+	args := make([]any, 0, len(posts))
+	for i, post := range posts {
+		index[post.PostID] = i
+		args = append(args, post.PostID)
+	}
 	query := `
 	SELECT
-		p.id,
-		p.user_id,
-		p.title,
-		p.data,
-		u.username
+		tp.post_id,
+		t.name
 	FROM
-		post p
-	INNER JOIN users u on p.user_id = u.id
-	INNER JOIN post_vote pv ON p.id = pv.post_id
+		tags t
+		INNER JOIN tag_and_post tp ON t.id = tp.tag_id
 	WHERE
-		pv.user_id = $1 AND pv.vote = %d
+		tp.post_id IN (?` + strings.Repeat(", ?", len(posts)-1) + `);
 	`
-	if islike {
-		query = fmt.Sprintf(query, 1)
-	} else {
-		query = fmt.Sprintf(query, 0)
-	}
 	prep, err := r.db.PrepareContext(ctx, query)
 	if err != nil {
-		return nil, http.StatusInternalServerError, err
+		return http.StatusInternalServerError, err
 	}
-	rows, err := prep.QueryContext(ctx, userID)
+	defer prep.Close()
+	rows, err := prep.QueryContext(ctx, args...)
 	if err != nil {
-		return nil, http.StatusBadRequest, err
+		return http.StatusInternalServerError, err
 	}
+	defer rows.Close()
 	for rows.Next() {
-		post := entity.Post{}
-		if err := rows.Scan(&post.PostID, &post.UserID, &post.Title, &post.Data, &post.UserName); err != nil {
-			return nil, http.StatusInternalServerError, err
-		}
-		tags, status, err := r.getTagsByPostID(ctx, post.PostID)
-		if err != nil {
-			return nil, status, err
+		var postID uint
+		var tag string
+		if err := rows.Scan(&postID, &tag); err != nil {
+			return http.StatusInternalServerError, err
 		}
-		post.Tags = tags
-		posts = append(posts, post)
+		i := index[postID]
+		posts[i].Tags = append(posts[i].Tags, tag)
 	}
-	return posts, http.StatusOK, nil
+	return http.StatusOK, nil
 }
 
 func (r *PostRepository) GetPostByID(ctx context.Context, postID uint) (entity.Post, int, error) {
@@ -146,7 +225,16 @@ func (r *PostRepository) GetPostByID(ctx context.Context, postID uint) (entity.P
 		p.user_id,
 		p.title,
 		p.data,
+		p.locked,
+		p.pinned,
+		p.status,
+		p.publish_at,
+		COALESCE(p.accepted_comment_id, 0),
+		p.edited_at,
+		p.created_at,
+		p.updated_at,
 		u.username,
+		u.reputation,
 		COALESCE(COUNT(CASE WHEN pv.vote = 1 THEN 1 END), 0) AS voting,
 		COALESCE(COUNT(CASE WHEN pv.vote = 0 THEN 1 END), 0) AS voting1
 	FROM
@@ -160,9 +248,12 @@ func (r *PostRepository) GetPostByID(ctx context.Context, postID uint) (entity.P
 	if err != nil {
 		return post, http.StatusInternalServerError, err
 	}
-	if err := prep.QueryRowContext(ctx, postID).Scan(&post.PostID, &post.UserID, &post.Title, &post.Data, &post.UserName, &post.Likes, &post.Dislikes); err != nil {
+	var publishAt, editedAt sql.NullTime
+	if err := prep.QueryRowContext(ctx, postID).Scan(&post.PostID, &post.UserID, &post.Title, &post.Data, &post.Locked, &post.Pinned, &post.Status, &publishAt, &post.AcceptedCommentID, &editedAt, &post.CreatedAt, &post.UpdatedAt, &post.UserName, &post.UserReputation, &post.Likes, &post.Dislikes); err != nil {
 		return post, http.StatusNotFound, err
 	}
+	post.PublishAt = timePtr(publishAt)
+	post.EditedAt = timePtr(editedAt)
 	tags, status, err := r.getTagsByPostID(ctx, postID)
 	if err != nil {
 		return post, status, err
@@ -173,7 +264,12 @@ func (r *PostRepository) GetPostByID(ctx context.Context, postID uint) (entity.P
 	if err != nil {
 		return post, status, err
 	}
-	post.Comments = comments
+	post.Comments = acceptedFirst(comments, post.AcceptedCommentID)
+	for _, comment := range comments {
+		if !comment.Deleted {
+			post.CommentCount++
+		}
+	}
 	return post, http.StatusOK, nil
 }
 
@@ -182,8 +278,14 @@ func (r *PostRepository) getCommentsByPostID(ctx context.Context, postID uint) (
 	SELECT 
 		c.id,
 		c.user_id,
+		COALESCE(c.parent_id, 0),
 		c.data,
+		c.deleted,
+		c.edited_at,
+		c.created_at,
+		c.updated_at,
 		u.username,
+		u.reputation,
 		COALESCE(COUNT(CASE WHEN cv.vote = 1 THEN 1 END), 0) AS voting,
 		COALESCE(COUNT(CASE WHEN cv.vote = 0 THEN 1 END), 0) AS voting1
 	FROM 
@@ -193,7 +295,8 @@ func (r *PostRepository) getCommentsByPostID(ctx context.Context, postID uint) (
 	WHERE 
 		c.post_id = $1
 	GROUP BY
-		c.id, c.user_id, c.data, u.username;
+		c.id, c.user_id, c.data, u.username, u.reputation
+	ORDER BY c.id;
 	`
 	prep, err := r.db.PrepareContext(ctx, query)
 	if err != nil {
@@ -209,13 +312,62 @@ func (r *PostRepository) getCommentsByPostID(ctx context.Context, postID uint) (
 	comments := []entity.Comment{}
 	for rows.Next() {
 		comment := entity.Comment{}
-		if err := rows.Scan(&comment.CommentID, &comment.UserID, &comment.Data, &comment.UserName, &comment.Likes, &comment.Dislikes); err != nil {
+		var editedAt sql.NullTime
+		if err := rows.Scan(&comment.CommentID, &comment.UserID, &comment.ParentID, &comment.Data, &comment.Deleted, &editedAt, &comment.CreatedAt, &comment.UpdatedAt, &comment.UserName, &comment.UserReputation, &comment.Likes, &comment.Dislikes); err != nil {
 			return nil, http.StatusInternalServerError, err
 		}
+		comment.EditedAt = timePtr(editedAt)
 		comment.PostID = postID
+		if comment.Deleted {
+			comment.UserID, comment.UserName, comment.UserReputation = 0, "", 0
+		}
 		comments = append(comments, comment)
 	}
-	return comments, http.StatusOK, nil
+	return threadComments(comments), http.StatusOK, nil
+}
+
+// threadComments orders comments depth-first so that every reply follows its parent,
+// and sets their depth. Siblings keep their original order.
+func threadComments(comments []entity.Comment) []entity.Comment {
+	children := map[uint][]entity.Comment{}
+	for _, comment := range comments {
+		children[comment.ParentID] = append(children[comment.ParentID], comment)
+	}
+	thread := make([]entity.Comment, 0, len(comments))
+	var walk func(parentID uint, depth int)
+	walk = func(parentID uint, depth int) {
+		for _, comment := range children[parentID] {
+			comment.Depth = depth
+			thread = append(thread, comment)
+			walk(comment.CommentID, depth+1)
+		}
+	}
+	walk(0, 0)
+	return thread
+}
+
+// acceptedFirst moves the accepted comment and its replies to the front of the thread
+// and flags it.
+func acceptedFirst(thread []entity.Comment, acceptedID uint) []entity.Comment {
+	start := -1
+	for i, comment := range thread {
+		if comment.CommentID == acceptedID {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return thread
+	}
+	end := start + 1
+	for end < len(thread) && thread[end].Depth > thread[start].Depth {
+		end++
+	}
+	thread[start].Accepted = true
+	ordered := make([]entity.Comment, 0, len(thread))
+	ordered = append(ordered, thread[start:end]...)
+	ordered = append(ordered, thread[:start]...)
+	return append(ordered, thread[end:]...)
 }
 
 func (r *PostRepository) getTagsByPostID(ctx context.Context, postID uint) ([]string, int, error) {
@@ -246,14 +398,14 @@ func (r *PostRepository) getTagsByPostID(ctx context.Context, postID uint) ([]st
 }
 
 func (r *PostRepository) CreatePost(ctx context.Context, input entity.Post) (uint, int, error) {
-	query := `INSERT INTO post(user_id, title, data) VALUES($1, $2, $3) RETURNING id;`
+	query := `INSERT INTO post(user_id, title, data, status, publish_at, created_at, updated_at) VALUES($1, $2, $3, $4, $5, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP) RETURNING id;`
 	prep, err := r.db.PrepareContext(ctx, query)
 	if err != nil {
 		return 0, http.StatusInternalServerError, err
 	}
 	defer prep.Close()
 	var id uint
-	if err = prep.QueryRowContext(ctx, input.UserID, input.Title, input.Data).Scan(&id); err != nil {
+	if err = prep.QueryRowContext(ctx, input.UserID, input.Title, input.Data, input.Status, nullTime(input.PublishAt)).Scan(&id); err != nil {
 		return 0, http.StatusBadRequest, err
 	}
 	return id, http.StatusOK, nil
@@ -266,41 +418,426 @@ func (r *PostRepository) DeletePostByID(ctx context.Context, PostID uint, userID
 		return http.StatusInternalServerError, err
 	}
 	defer prep.Close()
-	if _, err := prep.ExecContext(ctx, PostID, userID); err != nil {
+	res, err := prep.ExecContext(ctx, PostID, userID)
+	if err != nil {
 		return http.StatusBadRequest, err
 	}
+	if n, err := res.RowsAffected(); err != nil {
+		return http.StatusInternalServerError, err
+	} else if n == 0 {
+		return http.StatusNotFound, sql.ErrNoRows
+	}
 	return http.StatusOK, nil
 }
 
-func (r *PostRepository) UpsertPostVote(ctx context.Context, input entity.PostVote) (int, error) {
-	query := "SELECT vote FROM post_vote WHERE user_id = $1 and post_id = $2;"
+// GetPostStatus returns the author, status, lock and accepted answer of the post.
+func (r *PostRepository) GetPostStatus(ctx context.Context, postID uint) (entity.Post, int, error) {
+	query := `SELECT id, user_id, status, locked, COALESCE(accepted_comment_id, 0) FROM post WHERE id = $1;`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return entity.Post{}, http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	var post entity.Post
+	if err := prep.QueryRowContext(ctx, postID).Scan(&post.PostID, &post.UserID, &post.Status, &post.Locked, &post.AcceptedCommentID); err != nil {
+		if err == sql.ErrNoRows {
+			return entity.Post{}, http.StatusNotFound, err
+		}
+		return entity.Post{}, http.StatusInternalServerError, err
+	}
+	return post, http.StatusOK, nil
+}
+
+// answerReputation adds $1 to the reputation of the author of the accepted answer of
+// the post $2, unless the post author answered their own question.
+const answerReputation = `
+	UPDATE users SET reputation = reputation + $1
+	WHERE id = (
+		SELECT c.user_id FROM post p INNER JOIN comment c ON c.id = p.accepted_comment_id
+		WHERE p.id = $2 AND c.user_id != p.user_id
+	);
+	`
+
+// SetAcceptedAnswer marks the comment as the accepted answer of the post, or clears
+// the answer for a commentID of 0. The author of the accepted comment gains reward
+// reputation and the author of the previous answer loses it again.
+func (r *PostRepository) SetAcceptedAnswer(ctx context.Context, postID uint, commentID uint, reward int) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, answerReputation, -reward, postID); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	res, err := tx.ExecContext(ctx, `UPDATE post SET accepted_comment_id = $1 WHERE id = $2;`, nullID(commentID), postID)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return http.StatusInternalServerError, err
+	} else if n == 0 {
+		return http.StatusNotFound, sql.ErrNoRows
+	}
+	if _, err := tx.ExecContext(ctx, answerReputation, reward, postID); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if err := tx.Commit(); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (r *PostRepository) IsPostLocked(ctx context.Context, postID uint) (bool, int, error) {
+	query := `SELECT locked FROM post WHERE id = $1;`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return false, http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	var locked bool
+	if err := prep.QueryRowContext(ctx, postID).Scan(&locked); err != nil {
+		if err == sql.ErrNoRows {
+			return false, http.StatusNotFound, err
+		}
+		return false, http.StatusInternalServerError, err
+	}
+	return locked, http.StatusOK, nil
+}
+
+func (r *PostRepository) SetPostLocked(ctx context.Context, postID uint, locked bool) (int, error) {
+	return r.setPostFlag(ctx, `UPDATE post SET locked = $1 WHERE id = $2;`, postID, locked)
+}
+
+func (r *PostRepository) SetPostPinned(ctx context.Context, postID uint, pinned bool) (int, error) {
+	return r.setPostFlag(ctx, `UPDATE post SET pinned = $1 WHERE id = $2;`, postID, pinned)
+}
+
+func (r *PostRepository) setPostFlag(ctx context.Context, query string, postID uint, value bool) (int, error) {
 	prep, err := r.db.PrepareContext(ctx, query)
 	if err != nil {
 		return http.StatusInternalServerError, err
 	}
 	defer prep.Close()
+	res, err := prep.ExecContext(ctx, value, postID)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return http.StatusInternalServerError, err
+	} else if n == 0 {
+		return http.StatusNotFound, sql.ErrNoRows
+	}
+	return http.StatusOK, nil
+}
+
+func (r *PostRepository) DeleteAnyPostByID(ctx context.Context, PostID uint) (int, error) {
+	query := `DELETE FROM post WHERE id = $1`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	res, err := prep.ExecContext(ctx, PostID)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return http.StatusInternalServerError, err
+	} else if n == 0 {
+		return http.StatusNotFound, sql.ErrNoRows
+	}
+	return http.StatusOK, nil
+}
+
+// GetPostVote returns the vote of the user on the post, 1 for a like and 0 for a dislike.
+func (r *PostRepository) GetPostVote(ctx context.Context, postID uint, userID uint) (int, int, error) {
+	query := "SELECT vote FROM post_vote WHERE user_id = $1 and post_id = $2;"
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, http.StatusInternalServerError, err
+	}
+	defer prep.Close()
 	var vote int
+	if err := prep.QueryRowContext(ctx, userID, postID).Scan(&vote); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, http.StatusNotFound, err
+		}
+		return 0, http.StatusInternalServerError, err
+	}
+	return vote, http.StatusOK, nil
+}
+
+// UpsertPostVote adds the vote of the user, changes it, or removes it when the user
+// votes the same way again. The reputation of the post author changes by the weight of
+// the new vote minus the weight of the old one, votes on own posts do not count.
+func (r *PostRepository) UpsertPostVote(ctx context.Context, input entity.PostVote, weights entity.VoteWeights) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	defer tx.Rollback()
+	query := "SELECT vote FROM post_vote WHERE user_id = $1 and post_id = $2;"
+	prep, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	var vote, reputation int
 	if err := prep.QueryRowContext(ctx, input.UserID, input.PostID).Scan(&vote); err != nil {
 		if err == sql.ErrNoRows {
-			query = "INSERT INTO post_vote(user_id, post_id, vote) VALUES($1, $2, $3);"
-			if _, err = r.db.ExecContext(ctx, query, input.UserID, input.PostID, input.Vote); err != nil {
+			query = "INSERT INTO post_vote(user_id, post_id, vote, created_at, updated_at) VALUES($1, $2, $3, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP);"
+			if _, err = tx.ExecContext(ctx, query, input.UserID, input.PostID, input.Vote); err != nil {
 				return http.StatusBadRequest, err
 			}
+			reputation = weights.Of(input.Vote)
 		} else {
 			return http.StatusInternalServerError, err
 		}
 	} else {
 		if vote == input.Vote {
 			query = "DELETE FROM post_vote WHERE user_id = $1 and post_id = $2;"
-			if _, err := r.db.ExecContext(ctx, query, input.UserID, input.PostID); err != nil {
+			if _, err := tx.ExecContext(ctx, query, input.UserID, input.PostID); err != nil {
 				return http.StatusInternalServerError, err
 			}
+			reputation = -weights.Of(vote)
 		} else {
-			query = "UPDATE post_vote SET vote = $1 WHERE user_id = $2 and post_id = $3;"
-			if _, err := r.db.ExecContext(ctx, query, input.Vote, input.UserID, input.PostID); err != nil {
+			query = "UPDATE post_vote SET vote = $1, updated_at = CURRENT_TIMESTAMP WHERE user_id = $2 and post_id = $3;"
+			if _, err := tx.ExecContext(ctx, query, input.Vote, input.UserID, input.PostID); err != nil {
 				return http.StatusInternalServerError, err
 			}
+			reputation = weights.Of(input.Vote) - weights.Of(vote)
+		}
+	}
+	query = "UPDATE users SET reputation = reputation + $1 WHERE id = (SELECT user_id FROM post WHERE id = $2) AND id != $3;"
+	if _, err := tx.ExecContext(ctx, query, reputation, input.PostID, input.UserID); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if err := tx.Commit(); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// UpdatePost saves the current title, data and tags of the post as a revision and
+// replaces them with the input in one transaction. Only the author can update a post.
+func (r *PostRepository) UpdatePost(ctx context.Context, input entity.Post) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	defer tx.Rollback()
+	query := `
+	INSERT INTO post_revision(post_id, editor_id, title, data, tags)
+	SELECT
+		p.id,
+		p.user_id,
+		p.title,
+		p.data,
+		COALESCE((SELECT json_group_array(t.name) FROM tag_and_post tp INNER JOIN tags t ON t.id = tp.tag_id WHERE tp.post_id = p.id), '[]')
+	FROM
+		post p
+	WHERE
+		p.id = $1 AND p.user_id = $2;
+	`
+	res, err := tx.ExecContext(ctx, query, input.PostID, input.UserID)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return http.StatusInternalServerError, err
+	} else if n == 0 {
+		return http.StatusNotFound, sql.ErrNoRows
+	}
+	query = `UPDATE post SET title = $1, data = $2, edited_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = $3;`
+	if _, err := tx.ExecContext(ctx, query, input.Title, input.Data, input.PostID); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if status, err := replaceTags(ctx, tx, input.PostID, input.Tags); err != nil {
+		return status, err
+	}
+	if err := tx.Commit(); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// RetagPost saves the current version of the published post as a revision made by
+// editorID and replaces its tags in one transaction.
+func (r *PostRepository) RetagPost(ctx context.Context, postID uint, editorID uint, tags []string) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	defer tx.Rollback()
+	query := `
+	INSERT INTO post_revision(post_id, editor_id, title, data, tags)
+	SELECT
+		p.id,
+		$1,
+		p.title,
+		p.data,
+		COALESCE((SELECT json_group_array(t.name) FROM tag_and_post tp INNER JOIN tags t ON t.id = tp.tag_id WHERE tp.post_id = p.id), '[]')
+	FROM
+		post p
+	WHERE
+		p.id = $2 AND p.status = 'published';
+	`
+	res, err := tx.ExecContext(ctx, query, editorID, postID)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return http.StatusInternalServerError, err
+	} else if n == 0 {
+		return http.StatusNotFound, sql.ErrNoRows
+	}
+	query = `UPDATE post SET edited_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = $1;`
+	if _, err := tx.ExecContext(ctx, query, postID); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if status, err := replaceTags(ctx, tx, postID, tags); err != nil {
+		return status, err
+	}
+	if err := tx.Commit(); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// UpdateDraft replaces the title, data, tags, status and publish time of a draft or
+// scheduled post of the author without keeping a revision. A draft that is published
+// gets the current time as its creation time.
+func (r *PostRepository) UpdateDraft(ctx context.Context, input entity.Post) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	defer tx.Rollback()
+	query := `
+	UPDATE post SET
+		title = $1,
+		data = $2,
+		status = $3,
+		publish_at = $4,
+		created_at = CASE WHEN $3 = 'published' THEN CURRENT_TIMESTAMP ELSE created_at END,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE
+		id = $5 AND user_id = $6 AND status != 'published';
+	`
+	res, err := tx.ExecContext(ctx, query, input.Title, input.Data, input.Status, nullTime(input.PublishAt), input.PostID, input.UserID)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return http.StatusInternalServerError, err
+	} else if n == 0 {
+		return http.StatusNotFound, sql.ErrNoRows
+	}
+	if status, err := replaceTags(ctx, tx, input.PostID, input.Tags); err != nil {
+		return status, err
+	}
+	if err := tx.Commit(); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func replaceTags(ctx context.Context, tx *sql.Tx, postID uint, tags []string) (int, error) {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM tag_and_post WHERE post_id = $1;`, postID); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	for _, tag := range tags {
+		if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO tags(name) VALUES ($1);`, tag); err != nil {
+			return http.StatusInternalServerError, err
+		}
+		query := `INSERT OR IGNORE INTO tag_and_post(tag_id, post_id) SELECT id, $1 FROM tags WHERE name = $2;`
+		if _, err := tx.ExecContext(ctx, query, postID, tag); err != nil {
+			return http.StatusInternalServerError, err
 		}
 	}
 	return http.StatusOK, nil
 }
+
+// PublishDuePosts publishes the scheduled posts whose publish time is not after now
+// and returns them. The publish time becomes their creation time.
+func (r *PostRepository) PublishDuePosts(ctx context.Context, now time.Time) ([]entity.Post, int, error) {
+	query := `
+	UPDATE post SET
+		status = 'published',
+		created_at = publish_at,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE
+		status = 'scheduled' AND publish_at <= $1
+	RETURNING id, user_id, title;
+	`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	rows, err := prep.QueryContext(ctx, now.UTC().Format(sqliteTime))
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	defer rows.Close()
+	posts := []entity.Post{}
+	for rows.Next() {
+		post := entity.Post{Status: entity.PostPublished}
+		if err := rows.Scan(&post.PostID, &post.UserID, &post.Title); err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return posts, http.StatusOK, nil
+}
+
+// GetPostRevisions returns up to limit previous versions of the post after the
+// revision afterID, oldest first.
+func (r *PostRepository) GetPostRevisions(ctx context.Context, postID uint, afterID uint, limit int) ([]entity.PostRevision, int, error) {
+	query := `
+	SELECT
+		pr.id,
+		pr.post_id,
+		COALESCE(pr.editor_id, 0),
+		COALESCE(u.username, ''),
+		pr.title,
+		pr.data,
+		pr.tags,
+		pr.created_at
+	FROM
+		post_revision pr
+		LEFT JOIN users u ON u.id = pr.editor_id
+	WHERE
+		pr.post_id = $1
+		AND pr.id > $2
+	ORDER BY pr.id
+	LIMIT $3;
+	`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	rows, err := prep.QueryContext(ctx, postID, afterID, limit)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	defer rows.Close()
+	revisions := []entity.PostRevision{}
+	for rows.Next() {
+		revision := entity.PostRevision{}
+		var tags string
+		if err := rows.Scan(&revision.ID, &revision.PostID, &revision.EditorID, &revision.EditorName, &revision.Title, &revision.Data, &tags, &revision.CreatedAt); err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		if err := json.Unmarshal([]byte(tags), &revision.Tags); err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, http.StatusOK, nil
+}
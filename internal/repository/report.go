@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"forum/internal/entity"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var ErrAlreadyReported = errors.New("already reported")
+
+type ReportRepository struct {
+	db *sql.DB
+}
+
+func newReportRepository(db *sql.DB) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+// GetTargetAuthor returns the author of the reported post or comment, or the reported
+// user itself. Drafts and scheduled posts are not found, like posts that do not exist.
+func (r *ReportRepository) GetTargetAuthor(ctx context.Context, targetType string, targetID uint) (uint, int, error) {
+	var query string
+	switch targetType {
+	case "post":
+		query = `SELECT user_id FROM post WHERE id = $1 AND status = 'published';`
+	case "comment":
+		query = `SELECT user_id FROM comment WHERE id = $1 AND deleted = 0;`
+	case "user":
+		query = `SELECT id FROM users WHERE id = $1;`
+	default:
+		return 0, http.StatusBadRequest, errors.New("invalid target type")
+	}
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	var authorID uint
+	if err := prep.QueryRowContext(ctx, targetID).Scan(&authorID); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, http.StatusNotFound, err
+		}
+		return 0, http.StatusInternalServerError, err
+	}
+	return authorID, http.StatusOK, nil
+}
+
+// CreateReport adds the report to the open report of the target, opening one if there
+// is none. It returns ErrAlreadyReported when the reporter has already reported it.
+func (r *ReportRepository) CreateReport(ctx context.Context, input entity.ReportInput) (uint, int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, http.StatusInternalServerError, err
+	}
+	defer tx.Rollback()
+	query := `
+	INSERT INTO reports(target_type, target_id)
+	SELECT $1, $2 WHERE NOT EXISTS (SELECT 1 FROM reports WHERE target_type = $1 AND target_id = $2 AND status = 'open');
+	`
+	if _, err := tx.ExecContext(ctx, query, input.TargetType, input.TargetID); err != nil {
+		return 0, http.StatusBadRequest, err
+	}
+	var reportID uint
+	query = "SELECT id FROM reports WHERE target_type = $1 AND target_id = $2 AND status = 'open';"
+	if err := tx.QueryRowContext(ctx, query, input.TargetType, input.TargetID).Scan(&reportID); err != nil {
+		return 0, http.StatusInternalServerError, err
+	}
+	query = "INSERT INTO report_entries(report_id, reporter_id, category, comment) VALUES($1, $2, $3, $4);"
+	if _, err := tx.ExecContext(ctx, query, reportID, input.ReporterID, input.Category, input.Comment); err != nil {
+		if strings.HasPrefix(err.Error(), "UNIQUE constraint failed") {
+			return 0, http.StatusConflict, ErrAlreadyReported
+		}
+		return 0, http.StatusBadRequest, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, http.StatusInternalServerError, err
+	}
+	return reportID, http.StatusOK, nil
+}
+
+const reportSelect = `
+	SELECT
+		r.id,
+		r.target_type,
+		r.target_id,
+		COALESCE(CASE r.target_type
+			WHEN 'post' THEN (SELECT user_id FROM post WHERE id = r.target_id)
+			WHEN 'comment' THEN (SELECT user_id FROM comment WHERE id = r.target_id)
+			ELSE (SELECT id FROM users WHERE id = r.target_id)
+		END, 0),
+		r.status,
+		COUNT(e.id),
+		COALESCE(GROUP_CONCAT(DISTINCT e.category), ''),
+		r.created_at,
+		COALESCE(MAX(e.created_at), r.created_at)
+	FROM
+		reports r
+		LEFT JOIN report_entries e ON e.report_id = r.id
+	`
+
+// GetOpenReports returns the moderation queue, most reported first.
+func (r *ReportRepository) GetOpenReports(ctx context.Context) ([]entity.Report, int, error) {
+	query := reportSelect + `
+	WHERE r.status = 'open'
+	GROUP BY r.id
+	ORDER BY COUNT(e.id) DESC, r.id;
+	`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	rows, err := prep.QueryContext(ctx)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	defer rows.Close()
+	reports := []entity.Report{}
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, http.StatusOK, nil
+}
+
+func (r *ReportRepository) GetReportByID(ctx context.Context, reportID uint) (entity.Report, int, error) {
+	query := reportSelect + `
+	WHERE r.id = $1
+	GROUP BY r.id;
+	`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return entity.Report{}, http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	report, err := scanReport(prep.QueryRowContext(ctx, reportID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.Report{}, http.StatusNotFound, err
+		}
+		return entity.Report{}, http.StatusInternalServerError, err
+	}
+	return report, http.StatusOK, nil
+}
+
+// ResolveReport closes an open report with the given status.
+func (r *ReportRepository) ResolveReport(ctx context.Context, reportID uint, moderatorID uint, status string, note string) (int, error) {
+	query := `
+	UPDATE reports SET status = $1, resolved_by = $2, resolved_at = CURRENT_TIMESTAMP, note = $3
+	WHERE id = $4 AND status = 'open';
+	`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	res, err := prep.ExecContext(ctx, status, nullID(moderatorID), note, reportID)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return http.StatusInternalServerError, err
+	} else if n == 0 {
+		return http.StatusNotFound, sql.ErrNoRows
+	}
+	return http.StatusOK, nil
+}
+
+func scanReport(row interface{ Scan(...any) error }) (entity.Report, error) {
+	report := entity.Report{}
+	var categories string
+	var lastReportedAt string
+	if err := row.Scan(&report.ID, &report.TargetType, &report.TargetID, &report.AuthorID, &report.Status, &report.Count, &categories, &report.CreatedAt, &lastReportedAt); err != nil {
+		return report, err
+	}
+	// MAX() loses the DATETIME column type, so the driver returns plain text.
+	var err error
+	if report.LastReportedAt, err = time.Parse(sqliteTime, lastReportedAt); err != nil {
+		return report, err
+	}
+	report.Categories = []string{}
+	if categories != "" {
+		report.Categories = strings.Split(categories, ",")
+	}
+	return report, nil
+}
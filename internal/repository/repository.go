@@ -4,12 +4,17 @@ import (
 	"context"
 	"database/sql"
 	"forum/internal/entity"
+	"time"
 )
 
 type User interface {
-	Create(ctx context.Context, user entity.User) (int, error)
+	Create(ctx context.Context, user entity.User) (uint, int, error)
 	GetUserIDByEmail(ctx context.Context, email string) (entity.User, int, error)
 	GetUserByID(ctx context.Context, userID uint) (entity.User, int, error)
+	UpdatePassword(ctx context.Context, userID uint, hashPass string) (int, error)
+	GetAllUsers(ctx context.Context) ([]entity.User, int, error)
+	UpdateRole(ctx context.Context, userID uint, role entity.Role) (int, error)
+	RebuildReputation(ctx context.Context, weights entity.ReputationWeights) (int64, int, error)
 }
 
 type Session interface {
@@ -17,20 +22,40 @@ type Session interface {
 	DeleteSessionByToken(ctx context.Context, token string) error
 	PostSession(ctx context.Context, session entity.Session) (int, error)
 	DeleteSessionByUserID(ctx context.Context, userID uint) error
+	DeleteSessionByFamily(ctx context.Context, family string) error
+	GetSessionByRefreshHash(ctx context.Context, refreshHash string) (entity.Session, int, error)
+	RotateSession(ctx context.Context, sessionID uint, next entity.Session) (int, error)
+	DeleteUserSession(ctx context.Context, userID uint, family string) (int, error)
+	GetSessionsByUserID(ctx context.Context, userID uint) ([]entity.Session, int, error)
+	TouchSession(ctx context.Context, token string, client entity.Client) error
 }
 
 type Post interface {
 	CreatePost(ctx context.Context, input entity.Post) (uint, int, error)
 	DeletePostByID(ctx context.Context, PostID uint, userID uint) (int, error)
-	UpsertPostVote(ctx context.Context, input entity.PostVote) (int, error)
-	GetAllByTag(ctx context.Context, tagName string) ([]entity.Post, int, error)
+	DeleteAnyPostByID(ctx context.Context, PostID uint) (int, error)
+	GetPostStatus(ctx context.Context, postID uint) (entity.Post, int, error)
+	IsPostLocked(ctx context.Context, postID uint) (bool, int, error)
+	SetAcceptedAnswer(ctx context.Context, postID uint, commentID uint, reward int) (int, error)
+	SetPostLocked(ctx context.Context, postID uint, locked bool) (int, error)
+	SetPostPinned(ctx context.Context, postID uint, pinned bool) (int, error)
+	GetPostVote(ctx context.Context, postID uint, userID uint) (int, int, error)
+	UpsertPostVote(ctx context.Context, input entity.PostVote, weights entity.VoteWeights) (int, error)
+	UpdatePost(ctx context.Context, input entity.Post) (int, error)
+	RetagPost(ctx context.Context, postID uint, editorID uint, tags []string) (int, error)
+	UpdateDraft(ctx context.Context, input entity.Post) (int, error)
+	PublishDuePosts(ctx context.Context, now time.Time) ([]entity.Post, int, error)
+	GetPostRevisions(ctx context.Context, postID uint, afterID uint, limit int) ([]entity.PostRevision, int, error)
+	GetAllByTag(ctx context.Context, tagName string, sort entity.PostSort, unanswered bool, after entity.PostCursor, limit int) ([]entity.Post, int, error)
 	GetPostByID(ctx context.Context, postID uint) (entity.Post, int, error)
-	GetAllByUserID(ctx context.Context, userID uint) ([]entity.Post, int, error)
-	GetAllLikedPostsByUserID(ctx context.Context, userID uint, islike bool) ([]entity.Post, int, error)
+	GetAllByUserID(ctx context.Context, userID uint, after entity.PostCursor, limit int) ([]entity.Post, int, error)
+	GetDraftsByUserID(ctx context.Context, userID uint, after entity.PostCursor, limit int) ([]entity.Post, int, error)
+	GetAllLikedPostsByUserID(ctx context.Context, userID uint, islike bool, after entity.PostCursor, limit int) ([]entity.Post, int, error)
 }
 
 type Tag interface {
 	CreateTags(ctx context.Context, tagsName []string) (int, error)
+	GetNewTags(ctx context.Context, tagsName []string) ([]string, int, error)
 	GetTagsIDByName(ctx context.Context, tagsName []string) ([]uint, int, error)
 	CreateTagsAndPostCon(ctx context.Context, tagsID []uint, postID uint) (int, error)
 }
@@ -38,7 +63,55 @@ type Tag interface {
 type Comment interface {
 	CreateComment(ctx context.Context, input entity.Comment) (int, error)
 	DeleteComment(ctx context.Context, commentID uint, userID uint) (int, error)
-	UpsertCommentVote(ctx context.Context, input entity.CommentVote) (int, error)
+	DeleteAnyComment(ctx context.Context, commentID uint) (int, error)
+	GetCommentVote(ctx context.Context, commentID uint, userID uint) (int, int, error)
+	UpsertCommentVote(ctx context.Context, input entity.CommentVote, weights entity.VoteWeights) (int, error)
+	GetCommentByID(ctx context.Context, commentID uint) (entity.Comment, int, error)
+	UpdateComment(ctx context.Context, commentID uint, editorID uint, data string) (int, error)
+	GetCommentRevisions(ctx context.Context, commentID uint, afterID uint, limit int) ([]entity.CommentRevision, int, error)
+	GetCommentDepth(ctx context.Context, commentID uint) (int, int, error)
+}
+
+type Moderation interface {
+	CreateAction(ctx context.Context, action entity.ModerationAction) (int, error)
+	GetActions(ctx context.Context) ([]entity.ModerationAction, int, error)
+}
+
+type Audit interface {
+	CreateEntry(ctx context.Context, entry entity.AuditEntry) error
+	GetEntries(ctx context.Context, filter entity.AuditFilter) ([]entity.AuditEntry, int, error)
+}
+
+type Ban interface {
+	CreateBan(ctx context.Context, ban entity.Ban) (uint, int, error)
+	GetBans(ctx context.Context, userID uint) ([]entity.Ban, int, error)
+	LiftBan(ctx context.Context, banID uint, adminID uint) (int, error)
+}
+
+type Report interface {
+	GetTargetAuthor(ctx context.Context, targetType string, targetID uint) (uint, int, error)
+	CreateReport(ctx context.Context, input entity.ReportInput) (uint, int, error)
+	GetOpenReports(ctx context.Context) ([]entity.Report, int, error)
+	GetReportByID(ctx context.Context, reportID uint) (entity.Report, int, error)
+	ResolveReport(ctx context.Context, reportID uint, moderatorID uint, status string, note string) (int, error)
+}
+
+type Search interface {
+	Search(ctx context.Context, filter entity.SearchFilter) ([]entity.SearchResult, int, error)
+}
+
+type Attachment interface {
+	CreateAttachment(ctx context.Context, input entity.Attachment) (uint, int, error)
+	GetAttachmentByID(ctx context.Context, id uint) (entity.Attachment, int, error)
+	GetAttachmentsByPostID(ctx context.Context, postID uint) ([]entity.Attachment, int, error)
+}
+
+type Poll interface {
+	CreatePoll(ctx context.Context, input entity.Poll) (uint, int, error)
+	DeletePollByPostID(ctx context.Context, postID uint) (int, error)
+	GetPollByID(ctx context.Context, pollID uint) (entity.Poll, int, error)
+	GetPollByPostID(ctx context.Context, postID uint) (entity.Poll, int, error)
+	CreatePollVote(ctx context.Context, input entity.PollVote) (int, error)
 }
 
 type Repository struct {
@@ -47,14 +120,28 @@ type Repository struct {
 	Session
 	Tag
 	Comment
+	Moderation
+	Audit
+	Ban
+	Report
+	Search
+	Attachment
+	Poll
 }
 
 func NewRepository(db *sql.DB) *Repository {
 	return &Repository{
-		User:    newUserRepository(db),
-		Session: newSessionRepository(db),
-		Post:    newPostRepository(db),
-		Tag:     newTagRepository(db),
-		Comment: newCommentRepository(db),
+		User:       newUserRepository(db),
+		Session:    newSessionRepository(db),
+		Post:       newPostRepository(db),
+		Tag:        newTagRepository(db),
+		Comment:    newCommentRepository(db),
+		Moderation: newModerationRepository(db),
+		Audit:      newAuditRepository(db),
+		Ban:        newBanRepository(db),
+		Report:     newReportRepository(db),
+		Search:     newSearchRepository(db),
+		Attachment: newAttachmentRepository(db),
+		Poll:       newPollRepository(db),
 	}
 }
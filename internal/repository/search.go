@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"forum/internal/entity"
+	"net/http"
+)
+
+type SearchRepository struct {
+	db *sql.DB
+}
+
+func newSearchRepository(db *sql.DB) *SearchRepository {
+	return &SearchRepository{db: db}
+}
+
+// Search runs the full-text query over published posts and their comments and returns
+// up to filter.Limit results after the cursor, best BM25 rank first. Matches in the
+// post title weigh twice as much as matches in the text. The snippets mark the
+// matched terms with \x02 and \x03.
+func (r *SearchRepository) Search(ctx context.Context, filter entity.SearchFilter) ([]entity.SearchResult, int, error) {
+	query := `
+	SELECT type, post_id, comment_id, user_id, username, title, snippet, rank, created_at
+	FROM (
+		SELECT
+			'post' AS type,
+			p.id AS post_id,
+			0 AS comment_id,
+			p.user_id,
+			u.username,
+			p.title,
+			snippet(post_fts, -1, char(2), char(3), '...', 16) AS snippet,
+			bm25(post_fts, 2.0, 1.0) AS rank,
+			p.created_at,
+			p.id AS id
+		FROM
+			post_fts
+			INNER JOIN post p ON p.id = post_fts.rowid
+			INNER JOIN users u ON u.id = p.user_id
+		WHERE
+			post_fts MATCH $1
+			AND p.status = 'published'
+			AND ($2 = '' OR EXISTS (
+				SELECT 1 FROM tag_and_post tp INNER JOIN tags t ON t.id = tp.tag_id WHERE tp.post_id = p.id AND t.name = $2
+			))
+			AND ($3 = '' OR u.username = $3)
+		UNION ALL
+		SELECT
+			'comment',
+			c.post_id,
+			c.id,
+			c.user_id,
+			u.username,
+			p.title,
+			snippet(comment_fts, 0, char(2), char(3), '...', 16),
+			bm25(comment_fts),
+			c.created_at,
+			c.id
+		FROM
+			comment_fts
+			INNER JOIN comment c ON c.id = comment_fts.rowid
+			INNER JOIN post p ON p.id = c.post_id
+			INNER JOIN users u ON u.id = c.user_id
+		WHERE
+			comment_fts MATCH $1
+			AND p.status = 'published'
+			AND ($2 = '' OR EXISTS (
+				SELECT 1 FROM tag_and_post tp INNER JOIN tags t ON t.id = tp.tag_id WHERE tp.post_id = p.id AND t.name = $2
+			))
+			AND ($3 = '' OR u.username = $3)
+	)
+	WHERE
+		$4 = '' OR rank > $5 OR (rank = $5 AND (type > $4 OR (type = $4 AND id > $6)))
+	ORDER BY rank, type, id
+	LIMIT $7;
+	`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	after := filter.After
+	rows, err := prep.QueryContext(ctx, filter.Match, filter.Tag, filter.Author, after.Type, after.Rank, after.ID, filter.Limit)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	defer rows.Close()
+	results := []entity.SearchResult{}
+	for rows.Next() {
+		result := entity.SearchResult{}
+		var createdAt sql.NullTime
+		if err := rows.Scan(&result.Type, &result.PostID, &result.CommentID, &result.UserID, &result.UserName, &result.Title, &result.Snippet, &result.Rank, &createdAt); err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		result.CreatedAt = createdAt.Time
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return results, http.StatusOK, nil
+}
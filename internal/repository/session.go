@@ -3,10 +3,13 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"forum/internal/entity"
 	"net/http"
 )
 
+var ErrSessionRotated = errors.New("session is already rotated")
+
 type SessionRepository struct {
 	db *sql.DB
 }
@@ -17,7 +20,7 @@ func newSessionRepository(db *sql.DB) *SessionRepository {
 
 func (r *SessionRepository) IsTokenExist(ctx context.Context, token string) (bool, error) {
 	var exists bool
-	query := `SELECT EXISTS(SELECT 1 FROM sessions WHERE token = $1);`
+	query := `SELECT EXISTS(SELECT 1 FROM sessions WHERE token = $1 AND rotated = 0);`
 	prep, err := r.db.PrepareContext(ctx, query)
 	if err != nil {
 		return false, err
@@ -29,8 +32,9 @@ func (r *SessionRepository) IsTokenExist(ctx context.Context, token string) (boo
 	return exists, nil
 }
 
+// DeleteSessionByToken removes the whole token family that the access token belongs to.
 func (r *SessionRepository) DeleteSessionByToken(ctx context.Context, token string) error {
-	query := "DELETE FROM sessions WHERE token = $1;"
+	query := "DELETE FROM sessions WHERE family = (SELECT family FROM sessions WHERE token = $1 AND rotated = 0);"
 	prep, err := r.db.PrepareContext(ctx, query)
 	if err != nil {
 		return err
@@ -55,18 +59,143 @@ func (r *SessionRepository) DeleteSessionByUserID(ctx context.Context, userID ui
 	return nil
 }
 
-func (r *SessionRepository) PostSession(ctx context.Context, session entity.Session) (int, error) {
-	if err := r.DeleteSessionByUserID(ctx, session.UserID); err != nil {
+func (r *SessionRepository) DeleteSessionByFamily(ctx context.Context, family string) error {
+	query := "DELETE FROM sessions WHERE family = $1;"
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer prep.Close()
+	if _, err := prep.ExecContext(ctx, family); err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteUserSession removes one device session of the user.
+func (r *SessionRepository) DeleteUserSession(ctx context.Context, userID uint, family string) (int, error) {
+	query := "DELETE FROM sessions WHERE user_id = $1 AND family = $2;"
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
 		return http.StatusInternalServerError, err
 	}
-	query := `INSERT INTO sessions(token, user_id) VALUES($1, $2)`
+	defer prep.Close()
+	res, err := prep.ExecContext(ctx, userID, family)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return http.StatusInternalServerError, err
+	} else if n == 0 {
+		return http.StatusNotFound, sql.ErrNoRows
+	}
+	return http.StatusOK, nil
+}
+
+func (r *SessionRepository) GetSessionsByUserID(ctx context.Context, userID uint) ([]entity.Session, int, error) {
+	query := `
+	SELECT
+		id, family, user_id, token, expires_at, created_at, last_seen, ip, user_agent
+	FROM
+		sessions
+	WHERE
+		user_id = $1 AND rotated = 0
+	ORDER BY last_seen DESC;
+	`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	rows, err := prep.QueryContext(ctx, userID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	defer rows.Close()
+	sessions := []entity.Session{}
+	for rows.Next() {
+		session := entity.Session{}
+		if err := rows.Scan(&session.ID, &session.Family, &session.UserID, &session.Token, &session.ExpiresAt, &session.CreatedAt, &session.LastSeen, &session.IP, &session.UserAgent); err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, http.StatusOK, nil
+}
+
+// TouchSession updates last_seen of the session at most once a minute.
+func (r *SessionRepository) TouchSession(ctx context.Context, token string, client entity.Client) error {
+	query := `
+	UPDATE sessions SET last_seen = CURRENT_TIMESTAMP, ip = $1, user_agent = $2
+	WHERE token = $3 AND rotated = 0 AND last_seen < datetime('now', '-1 minute');
+	`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer prep.Close()
+	if _, err := prep.ExecContext(ctx, client.IP, client.UserAgent, token); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *SessionRepository) PostSession(ctx context.Context, session entity.Session) (int, error) {
+	query := `INSERT INTO sessions(family, user_id, token, refresh_hash, expires_at, ip, user_agent) VALUES($1, $2, $3, $4, $5, $6, $7)`
 	prep, err := r.db.PrepareContext(ctx, query)
 	if err != nil {
 		return http.StatusInternalServerError, err
 	}
 	defer prep.Close()
-	if _, err := prep.ExecContext(ctx, session.Token, session.UserID); err != nil {
+	if _, err := prep.ExecContext(ctx, session.Family, session.UserID, session.Token, session.RefreshHash, session.ExpiresAt, session.IP, session.UserAgent); err != nil {
 		return http.StatusBadRequest, err
 	}
 	return http.StatusOK, nil
 }
+
+func (r *SessionRepository) GetSessionByRefreshHash(ctx context.Context, refreshHash string) (entity.Session, int, error) {
+	session := entity.Session{}
+	query := `SELECT id, family, user_id, token, refresh_hash, expires_at, rotated FROM sessions WHERE refresh_hash = $1;`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return session, http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	if err := prep.QueryRowContext(ctx, refreshHash).Scan(&session.ID, &session.Family, &session.UserID, &session.Token, &session.RefreshHash, &session.ExpiresAt, &session.Rotated); err != nil {
+		if err == sql.ErrNoRows {
+			return session, http.StatusUnauthorized, err
+		}
+		return session, http.StatusInternalServerError, err
+	}
+	return session, http.StatusOK, nil
+}
+
+// RotateSession marks the session as used and inserts its successor in one transaction.
+// It returns ErrSessionRotated when the session has been rotated concurrently.
+func (r *SessionRepository) RotateSession(ctx context.Context, sessionID uint, next entity.Session) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	defer tx.Rollback()
+	res, err := tx.ExecContext(ctx, "UPDATE sessions SET rotated = 1, token = '' WHERE id = $1 AND rotated = 0;", sessionID)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return http.StatusInternalServerError, err
+	} else if n == 0 {
+		return http.StatusUnauthorized, ErrSessionRotated
+	}
+	query := `
+	INSERT INTO sessions(family, user_id, token, refresh_hash, expires_at, ip, user_agent, created_at)
+	SELECT $1, $2, $3, $4, $5, $6, $7, created_at FROM sessions WHERE id = $8;
+	`
+	if _, err := tx.ExecContext(ctx, query, next.Family, next.UserID, next.Token, next.RefreshHash, next.ExpiresAt, next.IP, next.UserAgent, sessionID); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if err := tx.Commit(); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
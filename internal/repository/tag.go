@@ -30,6 +30,27 @@ func (r *TagRepository) CreateTags(ctx context.Context, tagsName []string) (int,
 	return http.StatusOK, nil
 }
 
+// GetNewTags returns the names that no tag has yet.
+func (r *TagRepository) GetNewTags(ctx context.Context, tagsName []string) ([]string, int, error) {
+	query := "SELECT EXISTS(SELECT 1 FROM tags WHERE name = $1);"
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	names := []string{}
+	for _, tag := range tagsName {
+		var exists bool
+		if err := prep.QueryRowContext(ctx, tag).Scan(&exists); err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		if !exists {
+			names = append(names, tag)
+		}
+	}
+	return names, http.StatusOK, nil
+}
+
 func (r *TagRepository) GetTagsIDByName(ctx context.Context, tagsName []string) ([]uint, int, error) {
 
 	ids := []uint{}
@@ -15,18 +15,20 @@ func newUserRepository(db *sql.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
-func (r *UserRepository) Create(ctx context.Context, user entity.User) (int, error) {
-	query := `INSERT INTO users(username, email, hashPass)
-	VALUES($1, $2, $3) RETURNING id;`
+// Create inserts the user with its role and returns the ID of the new user.
+func (r *UserRepository) Create(ctx context.Context, user entity.User) (uint, int, error) {
+	query := `INSERT INTO users(username, email, hashPass, role, created_at, updated_at)
+	VALUES($1, $2, $3, $4, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP) RETURNING id;`
 	prep, err := r.db.PrepareContext(ctx, query)
 	if err != nil {
-		return http.StatusInternalServerError, err
+		return 0, http.StatusInternalServerError, err
 	}
 	defer prep.Close()
-	if _, err = prep.ExecContext(ctx, user.Username, user.Email, user.Password); err != nil {
-		return http.StatusBadRequest, err
+	var id uint
+	if err = prep.QueryRowContext(ctx, user.Username, user.Email, user.Password, user.Role).Scan(&id); err != nil {
+		return 0, http.StatusBadRequest, err
 	}
-	return http.StatusCreated, nil
+	return id, http.StatusCreated, nil
 }
 
 func (r *UserRepository) GetUserIDByEmail(ctx context.Context, email string) (entity.User, int, error) {
@@ -45,14 +47,119 @@ func (r *UserRepository) GetUserIDByEmail(ctx context.Context, email string) (en
 
 func (r *UserRepository) GetUserByID(ctx context.Context, userID uint) (entity.User, int, error) {
 	user := entity.User{}
-	query := `SELECT username, email FROM users WHERE id = $1 LIMIT 1;`
+	query := `SELECT id, username, email, role, reputation, created_at, updated_at FROM users WHERE id = $1 LIMIT 1;`
 	prep, err := r.db.PrepareContext(ctx, query)
 	if err != nil {
 		return user, http.StatusInternalServerError, err
 	}
 	defer prep.Close()
-	if err = prep.QueryRowContext(ctx, userID).Scan(&user.Username, &user.Email); err != nil {
+	if err = prep.QueryRowContext(ctx, userID).Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.Reputation, &user.CreatedAt, &user.UpdatedAt); err != nil {
 		return user, http.StatusNotFound, err
 	}
 	return user, http.StatusOK, nil
 }
+
+func (r *UserRepository) UpdatePassword(ctx context.Context, userID uint, hashPass string) (int, error) {
+	query := `UPDATE users SET hashPass = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2;`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	res, err := prep.ExecContext(ctx, hashPass, userID)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return http.StatusInternalServerError, err
+	} else if n == 0 {
+		return http.StatusNotFound, sql.ErrNoRows
+	}
+	return http.StatusOK, nil
+}
+
+func (r *UserRepository) GetAllUsers(ctx context.Context) ([]entity.User, int, error) {
+	query := `SELECT id, username, email, role, reputation, created_at, updated_at FROM users ORDER BY id;`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	rows, err := prep.QueryContext(ctx)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	defer rows.Close()
+	users := []entity.User{}
+	for rows.Next() {
+		user := entity.User{}
+		if err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.Reputation, &user.CreatedAt, &user.UpdatedAt); err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		users = append(users, user)
+	}
+	return users, http.StatusOK, nil
+}
+
+func (r *UserRepository) UpdateRole(ctx context.Context, userID uint, role entity.Role) (int, error) {
+	query := `UPDATE users SET role = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2;`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	res, err := prep.ExecContext(ctx, role, userID)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return http.StatusInternalServerError, err
+	} else if n == 0 {
+		return http.StatusNotFound, sql.ErrNoRows
+	}
+	return http.StatusOK, nil
+}
+
+// RebuildReputation recomputes the reputation of every user from the votes on their
+// posts and comments and their accepted answers, and returns the number of users whose
+// reputation changed. Votes on own posts and comments and accepted answers to own
+// posts do not count, like in the incremental updates.
+func (r *UserRepository) RebuildReputation(ctx context.Context, weights entity.ReputationWeights) (int64, int, error) {
+	query := `
+	WITH points(user_id, points) AS (
+		SELECT p.user_id, CASE pv.vote WHEN 1 THEN $1 ELSE $2 END
+		FROM post_vote pv INNER JOIN post p ON p.id = pv.post_id
+		WHERE pv.user_id != p.user_id
+		UNION ALL
+		SELECT c.user_id, CASE cv.vote WHEN 1 THEN $3 ELSE $4 END
+		FROM comment_vote cv INNER JOIN comment c ON c.id = cv.comment_id
+		WHERE cv.user_id != c.user_id
+		UNION ALL
+		SELECT c.user_id, $5
+		FROM post p INNER JOIN comment c ON c.id = p.accepted_comment_id
+		WHERE c.user_id != p.user_id
+	),
+	totals(user_id, reputation) AS (
+		SELECT u.id, COALESCE(SUM(pt.points), 0)
+		FROM users u LEFT JOIN points pt ON pt.user_id = u.id
+		GROUP BY u.id
+	)
+	UPDATE users SET reputation = totals.reputation
+	FROM totals
+	WHERE totals.user_id = users.id AND users.reputation != totals.reputation;
+	`
+	prep, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, http.StatusInternalServerError, err
+	}
+	defer prep.Close()
+	res, err := prep.ExecContext(ctx, weights.Post.Like, weights.Post.Dislike, weights.Comment.Like, weights.Comment.Dislike, weights.AcceptedAnswer)
+	if err != nil {
+		return 0, http.StatusInternalServerError, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, http.StatusInternalServerError, err
+	}
+	return n, http.StatusOK, nil
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"forum/internal/entity"
+	"forum/internal/repository"
+	"forum/pkg/blobstore"
+	"forum/pkg/config"
+	"forum/pkg/utils"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+type AttachmentService struct {
+	attachmentRepo repository.Attachment
+	postRepo       repository.Post
+	store          blobstore.BlobStore
+	cfg            config.Uploads
+}
+
+func newAttachmentService(attachmentRepo repository.Attachment, postRepo repository.Post, store blobstore.BlobStore, cfg config.Uploads) *AttachmentService {
+	return &AttachmentService{
+		attachmentRepo: attachmentRepo,
+		postRepo:       postRepo,
+		store:          store,
+		cfg:            cfg,
+	}
+}
+
+// Upload stores a file of the post author. The type of the file is sniffed from its
+// content, and images get their dimensions checked and a PNG thumbnail.
+func (s *AttachmentService) Upload(ctx context.Context, upload entity.Upload) (entity.Attachment, int, error) {
+	if len(upload.Data) == 0 {
+		return entity.Attachment{}, http.StatusBadRequest, errors.New("empty file")
+	} else if int64(len(upload.Data)) > s.cfg.MaxSize {
+		return entity.Attachment{}, http.StatusRequestEntityTooLarge, fmt.Errorf("file is larger than %d bytes", s.cfg.MaxSize)
+	}
+	post, status, err := s.postRepo.GetPostByID(ctx, upload.PostID)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return entity.Attachment{}, status, errors.New("post not found")
+		}
+		return entity.Attachment{}, status, err
+	}
+	if post.UserID != upload.UserID {
+		return entity.Attachment{}, http.StatusNotFound, errors.New("post not found")
+	} else if post.Locked {
+		return entity.Attachment{}, http.StatusForbidden, errors.New("thread is locked")
+	}
+	attachments, status, err := s.attachmentRepo.GetAttachmentsByPostID(ctx, upload.PostID)
+	if err != nil {
+		return entity.Attachment{}, status, err
+	}
+	if len(attachments) >= s.cfg.MaxFiles {
+		return entity.Attachment{}, http.StatusBadRequest, fmt.Errorf("a post can have at most %d attachments", s.cfg.MaxFiles)
+	}
+	attachment := entity.Attachment{
+		PostID:   upload.PostID,
+		UserID:   upload.UserID,
+		FileName: cleanFileName(upload.FileName),
+		MIME:     http.DetectContentType(upload.Data),
+		Size:     int64(len(upload.Data)),
+	}
+	if !slices.Contains(s.cfg.AllowedTypes, attachment.MIME) {
+		return entity.Attachment{}, http.StatusUnsupportedMediaType, fmt.Errorf("file type %s is not allowed", attachment.MIME)
+	}
+	var thumbnail []byte
+	if strings.HasPrefix(attachment.MIME, "image/") {
+		if thumbnail, status, err = s.checkImage(&attachment, upload.Data); err != nil {
+			return entity.Attachment{}, status, err
+		}
+	}
+	id, status, err := s.save(ctx, attachment, upload.Data, thumbnail)
+	if err != nil {
+		return entity.Attachment{}, status, err
+	}
+	return s.attachmentRepo.GetAttachmentByID(ctx, id)
+}
+
+// checkImage sets the dimensions of the image and returns its thumbnail. The
+// dimensions are checked before the image is decoded.
+func (s *AttachmentService) checkImage(attachment *entity.Attachment, data []byte) ([]byte, int, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, http.StatusBadRequest, errors.New("invalid image")
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > s.cfg.MaxDimension || cfg.Height > s.cfg.MaxDimension {
+		return nil, http.StatusBadRequest, fmt.Errorf("image must not be larger than %dx%d pixels", s.cfg.MaxDimension, s.cfg.MaxDimension)
+	}
+	attachment.Width, attachment.Height = cfg.Width, cfg.Height
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, http.StatusBadRequest, errors.New("invalid image")
+	}
+	var thumbnail bytes.Buffer
+	if err := png.Encode(&thumbnail, utils.Thumbnail(img, s.cfg.ThumbnailSize)); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return thumbnail.Bytes(), http.StatusOK, nil
+}
+
+// save puts the file and its thumbnail into the blob store and links them to the
+// post. The blobs are removed again when the attachment can not be saved.
+func (s *AttachmentService) save(ctx context.Context, attachment entity.Attachment, data, thumbnail []byte) (uint, int, error) {
+	name, err := randomToken(16)
+	if err != nil {
+		return 0, http.StatusInternalServerError, err
+	}
+	attachment.BlobKey = "attachments/" + name
+	if err := s.store.Put(ctx, attachment.BlobKey, bytes.NewReader(data)); err != nil {
+		return 0, http.StatusInternalServerError, err
+	}
+	if thumbnail != nil {
+		attachment.ThumbnailKey = "thumbnails/" + name + ".png"
+		if err := s.store.Put(ctx, attachment.ThumbnailKey, bytes.NewReader(thumbnail)); err != nil {
+			s.deleteBlobs(ctx, []entity.Attachment{attachment})
+			return 0, http.StatusInternalServerError, err
+		}
+	}
+	id, status, err := s.attachmentRepo.CreateAttachment(ctx, attachment)
+	if err != nil {
+		s.deleteBlobs(ctx, []entity.Attachment{attachment})
+		return 0, status, err
+	}
+	return id, http.StatusOK, nil
+}
+
+// Open returns the attachment and the content of the file, or of its thumbnail. The
+// attachments of drafts and scheduled posts are not served.
+func (s *AttachmentService) Open(ctx context.Context, id uint, thumbnail bool) (entity.Attachment, io.ReadCloser, int, error) {
+	attachment, status, err := s.attachmentRepo.GetAttachmentByID(ctx, id)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return attachment, nil, status, errors.New("attachment not found")
+		}
+		return attachment, nil, status, err
+	}
+	post, status, err := s.postRepo.GetPostStatus(ctx, attachment.PostID)
+	if err != nil {
+		return attachment, nil, status, err
+	}
+	if post.Status != entity.PostPublished {
+		return attachment, nil, http.StatusNotFound, errors.New("attachment not found")
+	}
+	key := attachment.BlobKey
+	if thumbnail {
+		if !attachment.HasThumbnail {
+			return attachment, nil, http.StatusNotFound, errors.New("attachment has no thumbnail")
+		}
+		key = attachment.ThumbnailKey
+	}
+	content, err := s.store.Open(ctx, key)
+	if err != nil {
+		if errors.Is(err, blobstore.ErrNotFound) {
+			return attachment, nil, http.StatusNotFound, errors.New("attachment not found")
+		}
+		return attachment, nil, http.StatusInternalServerError, err
+	}
+	return attachment, content, http.StatusOK, nil
+}
+
+func (s *AttachmentService) getAttachmentsByPostID(ctx context.Context, postID uint) ([]entity.Attachment, int, error) {
+	return s.attachmentRepo.GetAttachmentsByPostID(ctx, postID)
+}
+
+// deleteWithPost runs deletePost and removes the blobs of the post attachments once
+// the post and with it the attachment rows are gone.
+func (s *AttachmentService) deleteWithPost(ctx context.Context, postID uint, deletePost func() (int, error)) (int, error) {
+	attachments, status, err := s.attachmentRepo.GetAttachmentsByPostID(ctx, postID)
+	if err != nil {
+		return status, err
+	}
+	if status, err := deletePost(); err != nil {
+		return status, err
+	}
+	s.deleteBlobs(ctx, attachments)
+	return http.StatusOK, nil
+}
+
+// deleteBlobs removes the files of the attachments. Failures are logged, they leave
+// unreferenced blobs behind but do not affect the request.
+func (s *AttachmentService) deleteBlobs(ctx context.Context, attachments []entity.Attachment) {
+	for _, attachment := range attachments {
+		for _, key := range []string{attachment.BlobKey, attachment.ThumbnailKey} {
+			if key == "" {
+				continue
+			}
+			if err := s.store.Delete(ctx, key); err != nil {
+				log.Printf("attachment %d: delete blob %s: %s", attachment.ID, key, err)
+			}
+		}
+	}
+}
+
+// cleanFileName keeps the base name of an uploaded file without control characters.
+func cleanFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' || r == '\\' {
+			return -1
+		}
+		return r
+	}, filepath.Base(strings.ReplaceAll(name, `\`, "/")))
+	if name == "." || name == "/" || name == "" {
+		return "file"
+	}
+	if len(name) > 255 {
+		name = strings.ToValidUTF8(name[:255], "")
+	}
+	return name
+}
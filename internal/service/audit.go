@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"forum/internal/entity"
+	"forum/internal/repository"
+	"log"
+	"net/http"
+)
+
+// Auditor writes security- and moderation-relevant events to the audit log.
+type Auditor struct {
+	auditRepo repository.Audit
+}
+
+func newAuditor(auditRepo repository.Audit) *Auditor {
+	return &Auditor{auditRepo: auditRepo}
+}
+
+// Record stores an event done by actorID (0 for anonymous or system actors) with the
+// client ip from ctx. A failed write is logged and does not fail the event itself.
+func (a *Auditor) Record(ctx context.Context, actorID uint, action string, targetType string, targetID uint, detail string) {
+	if err := a.auditRepo.CreateEntry(ctx, entity.AuditEntry{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		IP:         clientFromContext(ctx).IP,
+		Detail:     detail,
+	}); err != nil {
+		log.Printf("cannot write audit log entry %s by %d: %v", action, actorID, err)
+	}
+}
+
+func (a *Auditor) GetAuditLog(ctx context.Context, filter entity.AuditFilter) ([]entity.AuditEntry, int, error) {
+	if filter.Limit == 0 {
+		filter.Limit = 100
+	} else if filter.Limit < 0 || filter.Limit > 500 {
+		return nil, http.StatusBadRequest, errors.New("invalid limit")
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return nil, http.StatusBadRequest, errors.New("invalid time range")
+	}
+	return a.auditRepo.GetEntries(ctx, filter)
+}
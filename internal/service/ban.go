@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"forum/internal/entity"
+	"forum/internal/repository"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type BanService struct {
+	banRepo     repository.Ban
+	userRepo    repository.User
+	sessionRepo repository.Session
+	auditor     *Auditor
+}
+
+func newBanService(banRepo repository.Ban, userRepo repository.User, sessionRepo repository.Session, auditor *Auditor) *BanService {
+	return &BanService{
+		banRepo:     banRepo,
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+		auditor:     auditor,
+	}
+}
+
+// BanUser bans the user until ban.ExpiresAt, or forever when it is nil, and signs the
+// user out of every device.
+func (s *BanService) BanUser(ctx context.Context, adminID uint, ban entity.Ban) (uint, int, error) {
+	if err := isValidReason(ban.Reason); err != nil {
+		return 0, http.StatusBadRequest, err
+	}
+	if ban.ExpiresAt != nil && !ban.ExpiresAt.After(time.Now()) {
+		return 0, http.StatusBadRequest, errors.New("expiry must be in the future")
+	}
+	user, status, err := s.userRepo.GetUserByID(ctx, ban.UserID)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return 0, status, errors.New("user not found")
+		}
+		return 0, status, err
+	}
+	if user.Role == entity.RoleAdmin {
+		return 0, http.StatusForbidden, errors.New("cannot ban an admin")
+	}
+	ban.AdminID = adminID
+	ban.Reason = strings.TrimSpace(ban.Reason)
+	banID, status, err := s.banRepo.CreateBan(ctx, ban)
+	if err != nil {
+		return 0, status, err
+	}
+	if err := s.sessionRepo.DeleteSessionByUserID(ctx, ban.UserID); err != nil {
+		return 0, http.StatusInternalServerError, err
+	}
+	detail := "permanent: " + ban.Reason
+	if ban.ExpiresAt != nil {
+		detail = "until " + ban.ExpiresAt.UTC().Format(time.RFC3339) + ": " + ban.Reason
+	}
+	s.auditor.Record(ctx, adminID, entity.AuditUserBan, "user", ban.UserID, detail)
+	return banID, http.StatusOK, nil
+}
+
+func (s *BanService) LiftBan(ctx context.Context, adminID uint, banID uint) (int, error) {
+	if status, err := s.banRepo.LiftBan(ctx, banID, adminID); err != nil {
+		if status == http.StatusNotFound {
+			return status, errors.New("ban not found")
+		}
+		return status, err
+	}
+	s.auditor.Record(ctx, adminID, entity.AuditBanLift, "ban", banID, "")
+	return http.StatusOK, nil
+}
+
+// GetActiveBans returns the bans and suspensions that are currently in force.
+func (s *BanService) GetActiveBans(ctx context.Context) ([]entity.Ban, int, error) {
+	bans, status, err := s.banRepo.GetBans(ctx, 0)
+	if err != nil {
+		return nil, status, err
+	}
+	now := time.Now()
+	active := []entity.Ban{}
+	for _, ban := range bans {
+		if ban.IsActive(now) {
+			active = append(active, ban)
+		}
+	}
+	return active, http.StatusOK, nil
+}
+
+// CheckBan returns 403 with the ban reason when the user is banned or suspended.
+func (s *BanService) CheckBan(ctx context.Context, userID uint) (int, error) {
+	return checkBan(ctx, s.banRepo, userID)
+}
+
+func checkBan(ctx context.Context, banRepo repository.Ban, userID uint) (int, error) {
+	bans, status, err := banRepo.GetBans(ctx, userID)
+	if err != nil {
+		return status, err
+	}
+	now := time.Now()
+	for _, ban := range bans {
+		if !ban.IsActive(now) {
+			continue
+		}
+		if ban.ExpiresAt == nil {
+			return http.StatusForbidden, fmt.Errorf("account is banned: %s", ban.Reason)
+		}
+		return http.StatusForbidden, fmt.Errorf("account is suspended until %s: %s", ban.ExpiresAt.UTC().Format(time.RFC3339), ban.Reason)
+	}
+	return http.StatusOK, nil
+}
@@ -3,35 +3,208 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"forum/internal/entity"
 	"forum/internal/repository"
+	"forum/pkg/utils"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
+// maxCommentLength is the longest comment text in bytes.
+const maxCommentLength = 2000
+
 type CommentService struct {
 	commentRepo repository.Comment
+	postRepo    repository.Post
+	auditor     *Auditor
+	maxDepth    int
+	reputation  *ReputationService
 }
 
-func newCommentService(commentRepo repository.Comment) *CommentService {
-	return &CommentService{commentRepo: commentRepo}
+func newCommentService(commentRepo repository.Comment, postRepo repository.Post, auditor *Auditor, maxDepth int, reputation *ReputationService) *CommentService {
+	return &CommentService{
+		commentRepo: commentRepo,
+		postRepo:    postRepo,
+		auditor:     auditor,
+		maxDepth:    maxDepth,
+		reputation:  reputation,
+	}
 }
 
 func (s *CommentService) CreateComment(ctx context.Context, input entity.Comment) (int, error) {
 	if strings.TrimSpace(input.Data) == "" {
 		return http.StatusBadRequest, errors.New("invalid data")
+	} else if len(input.Data) > maxCommentLength {
+		return http.StatusBadRequest, errors.New("data is too long")
 	} else if input.PostID == 0 {
 		return http.StatusBadRequest, errors.New("invalid postID")
 	}
+	post, status, err := s.postRepo.GetPostStatus(ctx, input.PostID)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return status, errors.New("post not found")
+		}
+		return status, err
+	}
+	if post.Status != entity.PostPublished {
+		return http.StatusNotFound, errors.New("post not found")
+	} else if post.Locked {
+		return http.StatusForbidden, errors.New("thread is locked")
+	}
+	if input.ParentID != 0 {
+		if status, err := s.checkParent(ctx, input); err != nil {
+			return status, err
+		}
+	}
+	if status, err := s.reputation.checkLinks(ctx, input.UserID, input.Data, ""); err != nil {
+		return status, err
+	}
 	return s.commentRepo.CreateComment(ctx, input)
 }
 
+// checkParent makes sure that a reply goes to a comment of the same post and does not
+// nest deeper than the configured maximum.
+func (s *CommentService) checkParent(ctx context.Context, input entity.Comment) (int, error) {
+	parent, status, err := s.commentRepo.GetCommentByID(ctx, input.ParentID)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return status, errors.New("parent comment not found")
+		}
+		return status, err
+	}
+	if parent.PostID != input.PostID {
+		return http.StatusBadRequest, errors.New("parent comment belongs to another post")
+	}
+	depth, status, err := s.commentRepo.GetCommentDepth(ctx, parent.CommentID)
+	if err != nil {
+		return status, err
+	}
+	if depth+1 > s.maxDepth {
+		return http.StatusBadRequest, fmt.Errorf("replies can not be nested deeper than %d levels", s.maxDepth)
+	}
+	return http.StatusOK, nil
+}
+
+// UpdateComment replaces the text of the comment and keeps the previous text as a
+// revision. Authors can edit their comments unless the thread is locked, moderators
+// can edit any comment.
+func (s *CommentService) UpdateComment(ctx context.Context, input entity.Comment, role entity.Role) (int, error) {
+	if strings.TrimSpace(input.Data) == "" {
+		return http.StatusBadRequest, errors.New("invalid data")
+	} else if len(input.Data) > maxCommentLength {
+		return http.StatusBadRequest, errors.New("data is too long")
+	}
+	comment, status, err := s.commentRepo.GetCommentByID(ctx, input.CommentID)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return status, errors.New("comment not found")
+		}
+		return status, err
+	}
+	moderator := role.Can(entity.PermModerate)
+	if comment.UserID != input.UserID && !moderator {
+		return http.StatusNotFound, errors.New("comment not found")
+	}
+	if !moderator {
+		locked, status, err := s.postRepo.IsPostLocked(ctx, comment.PostID)
+		if err != nil {
+			return status, err
+		}
+		if locked {
+			return http.StatusForbidden, errors.New("thread is locked")
+		}
+	}
+	if status, err := s.reputation.checkLinks(ctx, input.UserID, input.Data, comment.Data); err != nil {
+		return status, err
+	}
+	if status, err := s.commentRepo.UpdateComment(ctx, comment.CommentID, input.UserID, input.Data); err != nil {
+		if status == http.StatusNotFound {
+			return status, errors.New("comment not found")
+		}
+		return status, err
+	}
+	if comment.UserID != input.UserID {
+		s.auditor.Record(ctx, input.UserID, entity.AuditCommentEdit, "comment", comment.CommentID, "")
+	}
+	return http.StatusOK, nil
+}
+
+// GetCommentRevisions returns a page of the previous versions of the comment, each
+// with the diff of the edit that replaced it.
+func (s *CommentService) GetCommentRevisions(ctx context.Context, commentID uint, cursor string, limit int) (entity.CommentRevisionPage, int, error) {
+	after, limit, err := revisionPage(cursor, limit)
+	if err != nil {
+		return entity.CommentRevisionPage{}, http.StatusBadRequest, err
+	}
+	comment, status, err := s.commentRepo.GetCommentByID(ctx, commentID)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return entity.CommentRevisionPage{}, status, errors.New("comment not found")
+		}
+		return entity.CommentRevisionPage{}, status, err
+	}
+	// one revision more than the limit is the next version of the last one on the page
+	revisions, status, err := s.commentRepo.GetCommentRevisions(ctx, commentID, after, limit+1)
+	if err != nil {
+		return entity.CommentRevisionPage{}, status, err
+	}
+	page := entity.CommentRevisionPage{Revisions: revisions}
+	if len(revisions) > limit {
+		page.Revisions = revisions[:limit]
+		if page.NextCursor, err = encodeCursor(entity.RevisionCursor{ID: page.Revisions[limit-1].ID}); err != nil {
+			return entity.CommentRevisionPage{}, http.StatusInternalServerError, err
+		}
+	}
+	for i := range page.Revisions {
+		next := comment.Data
+		if i+1 < len(revisions) {
+			next = revisions[i+1].Data
+		}
+		page.Revisions[i].Diff = utils.LineDiff(page.Revisions[i].Data, next)
+	}
+	return page, http.StatusOK, nil
+}
+
 func (s *CommentService) DeleteComment(ctx context.Context, commentID uint, userID uint) (int, error) {
-	return s.commentRepo.DeleteComment(ctx, commentID, userID)
+	status, err := s.commentRepo.DeleteComment(ctx, commentID, userID)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return status, errors.New("comment not found")
+		}
+		return status, err
+	}
+	s.auditor.Record(ctx, userID, entity.AuditCommentDelete, "comment", commentID, "")
+	return http.StatusOK, nil
 }
+
 func (s *CommentService) UpsertCommentVote(ctx context.Context, input entity.CommentVote) (int, error) {
 	if input.Vote != 0 && input.Vote != 1 {
 		return http.StatusBadRequest, errors.New("invalid vote")
 	}
-	return s.commentRepo.UpsertCommentVote(ctx, input)
+	// deleted comments can not be voted on
+	if _, status, err := s.commentRepo.GetCommentByID(ctx, input.CommentID); err != nil {
+		if status == http.StatusNotFound {
+			return status, errors.New("comment not found")
+		}
+		return status, err
+	}
+	if input.Vote == 0 {
+		// voting 0 again removes a dislike, which does not need the privilege
+		vote, status, err := s.commentRepo.GetCommentVote(ctx, input.CommentID, input.UserID)
+		if err != nil && status != http.StatusNotFound {
+			return status, err
+		}
+		if err != nil || vote != 0 {
+			if status, err := s.reputation.require(ctx, input.UserID, privilegeDownvote, s.reputation.privileges.Downvote); err != nil {
+				return status, err
+			}
+		}
+	}
+	if status, err := s.commentRepo.UpsertCommentVote(ctx, input, s.reputation.weights.Comment); err != nil {
+		return status, err
+	}
+	s.auditor.Record(ctx, input.UserID, entity.AuditCommentVote, "comment", input.CommentID, strconv.Itoa(input.Vote))
+	return http.StatusOK, nil
 }
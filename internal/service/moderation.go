@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"forum/internal/entity"
+	"forum/internal/repository"
+	"net/http"
+	"strings"
+)
+
+type ModerationService struct {
+	moderationRepo repository.Moderation
+	postRepo       repository.Post
+	commentRepo    repository.Comment
+	auditor        *Auditor
+	attachments    *AttachmentService
+}
+
+func newModerationService(moderationRepo repository.Moderation, postRepo repository.Post, commentRepo repository.Comment, auditor *Auditor, attachments *AttachmentService) *ModerationService {
+	return &ModerationService{
+		moderationRepo: moderationRepo,
+		postRepo:       postRepo,
+		commentRepo:    commentRepo,
+		auditor:        auditor,
+		attachments:    attachments,
+	}
+}
+
+func (s *ModerationService) DeletePost(ctx context.Context, moderatorID uint, postID uint, reason string) (int, error) {
+	if err := isValidReason(reason); err != nil {
+		return http.StatusBadRequest, err
+	}
+	status, err := s.attachments.deleteWithPost(ctx, postID, func() (int, error) {
+		return s.postRepo.DeleteAnyPostByID(ctx, postID)
+	})
+	if err != nil {
+		if status == http.StatusNotFound {
+			return status, errors.New("post not found")
+		}
+		return status, err
+	}
+	return s.record(ctx, moderatorID, entity.ModDeletePost, "post", postID, reason)
+}
+
+func (s *ModerationService) DeleteComment(ctx context.Context, moderatorID uint, commentID uint, reason string) (int, error) {
+	if err := isValidReason(reason); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if status, err := s.commentRepo.DeleteAnyComment(ctx, commentID); err != nil {
+		if status == http.StatusNotFound {
+			return status, errors.New("comment not found")
+		}
+		return status, err
+	}
+	return s.record(ctx, moderatorID, entity.ModDeleteComment, "comment", commentID, reason)
+}
+
+func (s *ModerationService) LockPost(ctx context.Context, moderatorID uint, postID uint, locked bool, reason string) (int, error) {
+	if err := isValidReason(reason); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if status, err := s.postRepo.SetPostLocked(ctx, postID, locked); err != nil {
+		if status == http.StatusNotFound {
+			return status, errors.New("post not found")
+		}
+		return status, err
+	}
+	action := entity.ModLockPost
+	if !locked {
+		action = entity.ModUnlockPost
+	}
+	return s.record(ctx, moderatorID, action, "post", postID, reason)
+}
+
+func (s *ModerationService) PinPost(ctx context.Context, moderatorID uint, postID uint, pinned bool, reason string) (int, error) {
+	if err := isValidReason(reason); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if status, err := s.postRepo.SetPostPinned(ctx, postID, pinned); err != nil {
+		if status == http.StatusNotFound {
+			return status, errors.New("post not found")
+		}
+		return status, err
+	}
+	action := entity.ModPinPost
+	if !pinned {
+		action = entity.ModUnpinPost
+	}
+	return s.record(ctx, moderatorID, action, "post", postID, reason)
+}
+
+// WarnUser records a warning for the user in the moderation log.
+func (s *ModerationService) WarnUser(ctx context.Context, moderatorID uint, userID uint, reason string) (int, error) {
+	if err := isValidReason(reason); err != nil {
+		return http.StatusBadRequest, err
+	}
+	return s.record(ctx, moderatorID, entity.ModWarnUser, "user", userID, reason)
+}
+
+func (s *ModerationService) GetActions(ctx context.Context) ([]entity.ModerationAction, int, error) {
+	return s.moderationRepo.GetActions(ctx)
+}
+
+func (s *ModerationService) record(ctx context.Context, moderatorID uint, action string, targetType string, targetID uint, reason string) (int, error) {
+	s.auditor.Record(ctx, moderatorID, action, targetType, targetID, "moderation: "+strings.TrimSpace(reason))
+	return s.moderationRepo.CreateAction(ctx, entity.ModerationAction{
+		ModeratorID: moderatorID,
+		Action:      action,
+		TargetType:  targetType,
+		TargetID:    targetID,
+		Reason:      strings.TrimSpace(reason),
+	})
+}
+
+func isValidReason(reason string) error {
+	if strings.TrimSpace(reason) == "" || len(reason) > 500 {
+		return errors.New("invalid reason")
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"forum/internal/entity"
+	"net/http"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// getPostPage decodes the cursor, loads one post more than the limit with list to find
+// out whether there is a next page, and encodes the cursor of that page. The first page
+// starts at start, later pages must keep its sort.
+func getPostPage(cursor string, limit int, start entity.PostCursor, list func(after entity.PostCursor, limit int) ([]entity.Post, int, error)) (entity.PostPage, int, error) {
+	limit, err := pageLimit(limit)
+	if err != nil {
+		return entity.PostPage{}, http.StatusBadRequest, err
+	}
+	after := start
+	if cursor != "" {
+		if err := decodeCursor(cursor, &after); err != nil || after.ID == 0 {
+			return entity.PostPage{}, http.StatusBadRequest, errInvalidCursor
+		}
+		if after.Sort != start.Sort {
+			return entity.PostPage{}, http.StatusBadRequest, errors.New("cursor does not match the sort")
+		}
+	}
+	posts, status, err := list(after, limit+1)
+	if err != nil {
+		return entity.PostPage{}, status, err
+	}
+	page := entity.PostPage{Posts: posts}
+	if len(posts) > limit {
+		page.Posts = posts[:limit]
+		last := page.Posts[limit-1]
+		next := entity.PostCursor{ID: last.PostID, Pinned: last.Pinned, Score: last.Score, Sort: after.Sort, Time: after.Time}
+		if page.NextCursor, err = encodeCursor(next); err != nil {
+			return entity.PostPage{}, http.StatusInternalServerError, err
+		}
+	}
+	for i := range page.Posts {
+		renderPost(&page.Posts[i])
+	}
+	return page, http.StatusOK, nil
+}
+
+// pageLimit returns the default page size for a limit of 0 and rejects limits out of
+// range.
+func pageLimit(limit int) (int, error) {
+	if limit == 0 {
+		return defaultPageSize, nil
+	} else if limit < 0 || limit > maxPageSize {
+		return 0, errors.New("invalid limit")
+	}
+	return limit, nil
+}
+
+// revisionPage decodes the cursor of a revision list into the ID of the last revision
+// of the previous page and checks the limit. An empty cursor starts at the first
+// revision.
+func revisionPage(cursor string, limit int) (uint, int, error) {
+	limit, err := pageLimit(limit)
+	if err != nil {
+		return 0, 0, err
+	}
+	if cursor == "" {
+		return 0, limit, nil
+	}
+	var after entity.RevisionCursor
+	if err := decodeCursor(cursor, &after); err != nil || after.ID == 0 {
+		return 0, 0, errInvalidCursor
+	}
+	return after.ID, limit, nil
+}
+
+func encodeCursor(cursor any) (string, error) {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(cursor string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return errInvalidCursor
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return errInvalidCursor
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"forum/internal/entity"
+	"forum/internal/repository"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+type PollService struct {
+	pollRepo repository.Poll
+	postRepo repository.Post
+	auditor  *Auditor
+}
+
+func newPollService(pollRepo repository.Poll, postRepo repository.Post, auditor *Auditor) *PollService {
+	return &PollService{
+		pollRepo: pollRepo,
+		postRepo: postRepo,
+		auditor:  auditor,
+	}
+}
+
+// isValidPoll trims the question and options of the poll and checks their limits. A
+// close time must be in the future.
+func isValidPoll(poll *entity.Poll, now time.Time) error {
+	poll.Question = strings.TrimSpace(poll.Question)
+	if poll.Question == "" || len(poll.Question) > 200 {
+		return errors.New("invalid poll question")
+	} else if len(poll.Options) < 2 || len(poll.Options) > 10 {
+		return errors.New("a poll needs 2 to 10 options")
+	}
+	texts := make([]string, 0, len(poll.Options))
+	for i := range poll.Options {
+		text := strings.TrimSpace(poll.Options[i].Text)
+		if text == "" || len(text) > 100 {
+			return errors.New("invalid poll option")
+		} else if slices.Contains(texts, text) {
+			return errors.New("poll options must be different")
+		}
+		poll.Options[i].Text = text
+		texts = append(texts, text)
+	}
+	if poll.ClosesAt != nil && !poll.ClosesAt.After(now) {
+		return errors.New("poll close time must be in the future")
+	}
+	return nil
+}
+
+// Vote saves the ballot of the user and returns the updated poll. Single choice polls
+// take one option, and every user can vote once.
+func (s *PollService) Vote(ctx context.Context, input entity.PollVote) (entity.Poll, int, error) {
+	poll, status, err := s.pollRepo.GetPollByID(ctx, input.PollID)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return entity.Poll{}, status, errors.New("poll not found")
+		}
+		return entity.Poll{}, status, err
+	}
+	post, status, err := s.postRepo.GetPostStatus(ctx, poll.PostID)
+	if err != nil {
+		return entity.Poll{}, status, err
+	}
+	if post.Status != entity.PostPublished {
+		return entity.Poll{}, http.StatusNotFound, errors.New("poll not found")
+	} else if post.Locked {
+		return entity.Poll{}, http.StatusForbidden, errors.New("thread is locked")
+	} else if isClosed(poll, time.Now()) {
+		return entity.Poll{}, http.StatusForbidden, errors.New("poll is closed")
+	}
+	if err := checkChoice(poll, input.OptionIDs); err != nil {
+		return entity.Poll{}, http.StatusBadRequest, err
+	}
+	if status, err := s.pollRepo.CreatePollVote(ctx, input); err != nil {
+		if status == http.StatusConflict {
+			return entity.Poll{}, status, errors.New("you already voted in this poll")
+		}
+		return entity.Poll{}, status, err
+	}
+	s.auditor.Record(ctx, input.UserID, entity.AuditPollVote, "poll", poll.ID, "")
+	if poll, status, err = s.pollRepo.GetPollByID(ctx, poll.ID); err != nil {
+		return entity.Poll{}, status, err
+	}
+	poll.Closed = isClosed(poll, time.Now())
+	return poll, http.StatusOK, nil
+}
+
+// checkChoice makes sure that the options belong to the poll, are not repeated and
+// that single choice polls get one option.
+func checkChoice(poll entity.Poll, optionIDs []uint) error {
+	if len(optionIDs) == 0 {
+		return errors.New("choose an option")
+	} else if !poll.Multiple && len(optionIDs) > 1 {
+		return errors.New("choose only one option")
+	}
+	for i, id := range optionIDs {
+		if slices.Contains(optionIDs[:i], id) {
+			return errors.New("options are repeated")
+		}
+		if !slices.ContainsFunc(poll.Options, func(option entity.PollOption) bool { return option.ID == id }) {
+			return errors.New("invalid option")
+		}
+	}
+	return nil
+}
+
+func isClosed(poll entity.Poll, now time.Time) bool {
+	return poll.ClosesAt != nil && !now.Before(*poll.ClosesAt)
+}
+
+func (s *PollService) createPoll(ctx context.Context, postID uint, poll entity.Poll) (int, error) {
+	poll.PostID = postID
+	_, status, err := s.pollRepo.CreatePoll(ctx, poll)
+	return status, err
+}
+
+// replacePoll replaces the poll of a draft, which has no votes yet.
+func (s *PollService) replacePoll(ctx context.Context, postID uint, poll entity.Poll) (int, error) {
+	if status, err := s.pollRepo.DeletePollByPostID(ctx, postID); err != nil {
+		return status, err
+	}
+	return s.createPoll(ctx, postID, poll)
+}
+
+// getPollByPostID returns the poll of the post, or nil when the post has none.
+func (s *PollService) getPollByPostID(ctx context.Context, postID uint) (*entity.Poll, int, error) {
+	poll, status, err := s.pollRepo.GetPollByPostID(ctx, postID)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return nil, http.StatusOK, nil
+		}
+		return nil, status, err
+	}
+	poll.Closed = isClosed(poll, time.Now())
+	return &poll, http.StatusOK, nil
+}
@@ -5,36 +5,111 @@ import (
 	"errors"
 	"forum/internal/entity"
 	"forum/internal/repository"
+	"forum/pkg/markdown"
+	"forum/pkg/utils"
 	"log"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type PostService struct {
-	postRepo repository.Post
-	tagRepo  repository.Tag
+	postRepo    repository.Post
+	tagRepo     repository.Tag
+	commentRepo repository.Comment
+	auditor     *Auditor
+	attachments *AttachmentService
+	polls       *PollService
+	reputation  *ReputationService
 }
 
-func newPostService(postRepo repository.Post, tagRepo repository.Tag) *PostService {
+func newPostService(postRepo repository.Post, tagRepo repository.Tag, commentRepo repository.Comment, auditor *Auditor, attachments *AttachmentService, polls *PollService, reputation *ReputationService) *PostService {
 	return &PostService{
-		postRepo: postRepo,
-		tagRepo:  tagRepo,
+		postRepo:    postRepo,
+		tagRepo:     tagRepo,
+		commentRepo: commentRepo,
+		auditor:     auditor,
+		attachments: attachments,
+		polls:       polls,
+		reputation:  reputation,
 	}
 }
 
-func (s *PostService) CreatePost(ctx context.Context, input entity.Post) (uint, int, error) {
+func isValidPost(input entity.Post) error {
 	if input.Data == "" || len(input.Data) > 10000 {
-		return 0, http.StatusBadRequest, errors.New("data is empty")
+		return errors.New("data is empty")
 	} else if input.Title == "" || len(input.Title) > 58 {
-		return 0, http.StatusBadRequest, errors.New("title is empty")
-	} else if len(input.Tags) == 0 || len(input.Tags) > 5 {
-		return 0, http.StatusBadRequest, errors.New("tags is empty")
+		return errors.New("title is empty")
+	}
+	return isValidTags(input.Tags)
+}
+
+func isValidTags(tags []string) error {
+	if len(tags) == 0 || len(tags) > 5 {
+		return errors.New("tags is empty")
+	}
+	for _, tag := range tags {
+		if len(tag) == 0 || len(tag) > 20 {
+			return errors.New("invalid tag")
+		}
+	}
+	return nil
+}
+
+// isValidDraft checks the limits of a post but allows the title, data and tags of a
+// draft to be empty, so that unfinished posts can be saved.
+func isValidDraft(input entity.Post) error {
+	if len(input.Data) > 10000 {
+		return errors.New("data is too long")
+	} else if len(input.Title) > 58 {
+		return errors.New("title is too long")
+	} else if len(input.Tags) > 5 {
+		return errors.New("too many tags")
 	}
 	for _, tag := range input.Tags {
 		if len(tag) == 0 || len(tag) > 20 {
-			return 0, http.StatusBadRequest, errors.New("invalid tag")
+			return errors.New("invalid tag")
 		}
 	}
+	return nil
+}
+
+// checkStatus validates the post for its status. An empty status publishes the post,
+// and scheduled posts need a publish time in the future.
+func checkStatus(input *entity.Post, now time.Time) error {
+	if input.Status == "" {
+		input.Status = entity.PostPublished
+	}
+	if !entity.IsValidPostStatus(input.Status) {
+		return errors.New("invalid status")
+	}
+	if input.Status != entity.PostScheduled {
+		input.PublishAt = nil
+	} else if input.PublishAt == nil || !input.PublishAt.After(now) {
+		return errors.New("publish time must be in the future")
+	}
+	if input.Poll != nil {
+		if err := isValidPoll(input.Poll, now); err != nil {
+			return err
+		}
+	}
+	if input.Status == entity.PostDraft {
+		return isValidDraft(*input)
+	}
+	return isValidPost(*input)
+}
+
+// CreatePost creates a published post, a draft or a post that is scheduled for
+// publishing, depending on the status of the input, and its poll.
+func (s *PostService) CreatePost(ctx context.Context, input entity.Post) (uint, int, error) {
+	if err := checkStatus(&input, time.Now()); err != nil {
+		return 0, http.StatusBadRequest, err
+	}
+	if status, err := s.checkPrivileges(ctx, input, false); err != nil {
+		return 0, status, err
+	}
 	postID, status, err := s.postRepo.CreatePost(ctx, input)
 	if err != nil {
 		if _, Posterr := s.postRepo.DeletePostByID(ctx, postID, input.UserID); Posterr != nil {
@@ -62,35 +137,409 @@ func (s *PostService) CreatePost(ctx context.Context, input entity.Post) (uint,
 		}
 		return 0, status, err
 	}
+	if input.Poll != nil {
+		if status, err := s.polls.createPoll(ctx, postID, *input.Poll); err != nil {
+			if _, Posterr := s.postRepo.DeletePostByID(ctx, postID, input.UserID); Posterr != nil {
+				log.Println(Posterr)
+			}
+			return 0, status, err
+		}
+	}
 	return postID, http.StatusOK, nil
 }
 
+// UpdatePost replaces the title, data and tags of the post and keeps the previous
+// version as a revision. Only the author can edit a post and locked posts can not be edited.
+// Drafts and scheduled posts are saved without a revision, and the update can change
+// their status, publish time and poll. Published posts can not go back to drafts, and
+// their poll stays as it is.
+func (s *PostService) UpdatePost(ctx context.Context, input entity.Post) (int, error) {
+	post, status, err := s.postRepo.GetPostStatus(ctx, input.PostID)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return status, errors.New("post not found")
+		}
+		return status, err
+	}
+	if post.Status != entity.PostPublished {
+		if post.UserID != input.UserID {
+			return http.StatusNotFound, errors.New("post not found")
+		}
+		return s.updateDraft(ctx, input, post.Status)
+	}
+	if input.Status != "" && input.Status != entity.PostPublished {
+		return http.StatusBadRequest, errors.New("published posts can not be unpublished")
+	}
+	if err := isValidPost(input); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if post.Locked {
+		return http.StatusForbidden, errors.New("thread is locked")
+	}
+	if status, err := s.checkPrivileges(ctx, input, true); err != nil {
+		return status, err
+	}
+	input.Tags = append(input.Tags, "ALL")
+	if status, err := s.postRepo.UpdatePost(ctx, input); err != nil {
+		if status == http.StatusNotFound {
+			return status, errors.New("post not found")
+		}
+		return status, err
+	}
+	return http.StatusOK, nil
+}
+
+// RetagPost replaces the tags of a published post and keeps the previous version as a
+// revision. Users can retag the posts of other users once they have the privilege to,
+// locked posts can not be retagged.
+func (s *PostService) RetagPost(ctx context.Context, input entity.Post) (int, error) {
+	if err := isValidTags(input.Tags); err != nil {
+		return http.StatusBadRequest, err
+	}
+	post, status, err := s.postRepo.GetPostStatus(ctx, input.PostID)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return status, errors.New("post not found")
+		}
+		return status, err
+	}
+	if post.Status != entity.PostPublished {
+		return http.StatusNotFound, errors.New("post not found")
+	} else if post.Locked {
+		return http.StatusForbidden, errors.New("thread is locked")
+	}
+	if post.UserID != input.UserID {
+		if status, err := s.reputation.require(ctx, input.UserID, privilegeRetagOthers, s.reputation.privileges.RetagOthers); err != nil {
+			return status, err
+		}
+	}
+	if status, err := s.checkNewTags(ctx, input.UserID, input.Tags); err != nil {
+		return status, err
+	}
+	if status, err := s.postRepo.RetagPost(ctx, post.PostID, input.UserID, append(input.Tags, "ALL")); err != nil {
+		if status == http.StatusNotFound {
+			return status, errors.New("post not found")
+		}
+		return status, err
+	}
+	return http.StatusOK, nil
+}
+
+// checkPrivileges checks that the author has the privileges that the post needs: to
+// create its tags that do not exist yet and to post the links in its text. Edits only
+// need the links privilege for links that the stored version of the post does not have.
+func (s *PostService) checkPrivileges(ctx context.Context, input entity.Post, edit bool) (int, error) {
+	if status, err := s.checkNewTags(ctx, input.UserID, input.Tags); err != nil {
+		return status, err
+	}
+	old := ""
+	if edit && markdown.HasLinks(input.Data) {
+		stored, status, err := s.postRepo.GetPostByID(ctx, input.PostID)
+		if err != nil {
+			return status, err
+		}
+		old = stored.Data
+	}
+	return s.reputation.checkLinks(ctx, input.UserID, input.Data, old)
+}
+
+// checkNewTags checks that the user has the privilege to create the tags that do not
+// exist yet.
+func (s *PostService) checkNewTags(ctx context.Context, userID uint, tags []string) (int, error) {
+	newTags, status, err := s.tagRepo.GetNewTags(ctx, tags)
+	if err != nil {
+		return status, err
+	}
+	if len(newTags) == 0 {
+		return http.StatusOK, nil
+	}
+	privilege := privilegeCreateTag + " (" + strings.Join(newTags, ", ") + ")"
+	return s.reputation.require(ctx, userID, privilege, s.reputation.privileges.CreateTag)
+}
+
+// updateDraft saves a draft or scheduled post. An empty status of the input keeps the
+// current status.
+func (s *PostService) updateDraft(ctx context.Context, input entity.Post, current string) (int, error) {
+	if input.Status == "" {
+		input.Status = current
+	}
+	if err := checkStatus(&input, time.Now()); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if status, err := s.checkPrivileges(ctx, input, true); err != nil {
+		return status, err
+	}
+	input.Tags = append(input.Tags, "ALL")
+	if status, err := s.postRepo.UpdateDraft(ctx, input); err != nil {
+		if status == http.StatusNotFound {
+			return status, errors.New("post not found")
+		}
+		return status, err
+	}
+	if input.Poll != nil {
+		if status, err := s.polls.replacePoll(ctx, input.PostID, *input.Poll); err != nil {
+			return status, err
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// PublishDuePosts publishes the scheduled posts whose publish time has come.
+func (s *PostService) PublishDuePosts(ctx context.Context) ([]entity.Post, int, error) {
+	return s.postRepo.PublishDuePosts(ctx, time.Now())
+}
+
+// GetPostRevisions returns a page of the previous versions of the post, each with the
+// diff of the edit that replaced it.
+func (s *PostService) GetPostRevisions(ctx context.Context, postID uint, cursor string, limit int) (entity.PostRevisionPage, int, error) {
+	after, limit, err := revisionPage(cursor, limit)
+	if err != nil {
+		return entity.PostRevisionPage{}, http.StatusBadRequest, err
+	}
+	post, status, err := s.postRepo.GetPostByID(ctx, postID)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return entity.PostRevisionPage{}, status, errors.New("post not found")
+		}
+		return entity.PostRevisionPage{}, status, err
+	}
+	if post.Status != entity.PostPublished {
+		return entity.PostRevisionPage{}, http.StatusNotFound, errors.New("post not found")
+	}
+	// one revision more than the limit is the next version of the last one on the page
+	revisions, status, err := s.postRepo.GetPostRevisions(ctx, postID, after, limit+1)
+	if err != nil {
+		return entity.PostRevisionPage{}, status, err
+	}
+	page := entity.PostRevisionPage{Revisions: revisions}
+	if len(revisions) > limit {
+		page.Revisions = revisions[:limit]
+		if page.NextCursor, err = encodeCursor(entity.RevisionCursor{ID: page.Revisions[limit-1].ID}); err != nil {
+			return entity.PostRevisionPage{}, http.StatusInternalServerError, err
+		}
+	}
+	for i := range page.Revisions {
+		next := entity.PostRevision{Title: post.Title, Data: post.Data, Tags: post.Tags}
+		if i+1 < len(revisions) {
+			next = revisions[i+1]
+		}
+		page.Revisions[i].Tags = withoutTag(page.Revisions[i].Tags, "ALL")
+		nextTags := withoutTag(next.Tags, "ALL")
+		page.Revisions[i].Diff = entity.PostDiff{
+			Title:       utils.LineDiff(page.Revisions[i].Title, next.Title),
+			Data:        utils.LineDiff(page.Revisions[i].Data, next.Data),
+			TagsAdded:   tagsDifference(nextTags, page.Revisions[i].Tags),
+			TagsRemoved: tagsDifference(page.Revisions[i].Tags, nextTags),
+		}
+	}
+	return page, http.StatusOK, nil
+}
+
+func withoutTag(tags []string, name string) []string {
+	result := []string{}
+	for _, tag := range tags {
+		if tag != name {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// tagsDifference returns the tags of a that are not in b.
+func tagsDifference(a, b []string) []string {
+	result := []string{}
+	for _, tag := range a {
+		if !slices.Contains(b, tag) {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// GetPostByID returns a published post with its comments and attachments.
 func (s *PostService) GetPostByID(ctx context.Context, postID uint) (entity.Post, int, error) {
-	return s.postRepo.GetPostByID(ctx, postID)
+	post, status, err := s.postRepo.GetPostByID(ctx, postID)
+	if err != nil {
+		return post, status, err
+	}
+	if post.Status != entity.PostPublished {
+		return entity.Post{}, http.StatusNotFound, errors.New("post not found")
+	}
+	return s.loadPost(ctx, post)
+}
+
+// GetDraft returns a draft or scheduled post of the user.
+func (s *PostService) GetDraft(ctx context.Context, postID uint, userID uint) (entity.Post, int, error) {
+	post, status, err := s.postRepo.GetPostByID(ctx, postID)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return entity.Post{}, status, errors.New("draft not found")
+		}
+		return entity.Post{}, status, err
+	}
+	if post.Status == entity.PostPublished || post.UserID != userID {
+		return entity.Post{}, http.StatusNotFound, errors.New("draft not found")
+	}
+	return s.loadPost(ctx, post)
+}
+
+// loadPost sets the attachments, the poll and the HTML of the post.
+func (s *PostService) loadPost(ctx context.Context, post entity.Post) (entity.Post, int, error) {
+	attachments, status, err := s.attachments.getAttachmentsByPostID(ctx, post.PostID)
+	if err != nil {
+		return post, status, err
+	}
+	post.Attachments = attachments
+	if post.Poll, status, err = s.polls.getPollByPostID(ctx, post.PostID); err != nil {
+		return post, status, err
+	}
+	renderPost(&post)
+	return post, http.StatusOK, nil
+}
+
+// renderPost sets the HTML of the Markdown data of the post and its comments.
+func renderPost(post *entity.Post) {
+	post.DataHTML = markdown.Render(post.Data)
+	for i := range post.Comments {
+		if post.Comments[i].Deleted {
+			post.Comments[i].DataHTML = "<p>[deleted]</p>"
+			continue
+		}
+		post.Comments[i].DataHTML = markdown.Render(post.Comments[i].Data)
+	}
 }
 
 func (s *PostService) DeletePostByID(ctx context.Context, postID uint, userID uint) (int, error) {
-	return s.postRepo.DeletePostByID(ctx, postID, userID)
+	status, err := s.attachments.deleteWithPost(ctx, postID, func() (int, error) {
+		return s.postRepo.DeletePostByID(ctx, postID, userID)
+	})
+	if err != nil {
+		if status == http.StatusNotFound {
+			return status, errors.New("post not found")
+		}
+		return status, err
+	}
+	s.auditor.Record(ctx, userID, entity.AuditPostDelete, "post", postID, "")
+	return http.StatusOK, nil
 }
 
 func (s *PostService) UpsertPostVote(ctx context.Context, input entity.PostVote) (int, error) {
 	if input.Vote != 0 && input.Vote != 1 {
 		return http.StatusBadRequest, errors.New("invalid vote")
 	}
-	return s.postRepo.UpsertPostVote(ctx, input)
+	post, status, err := s.postRepo.GetPostStatus(ctx, input.PostID)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return status, errors.New("post not found")
+		}
+		return status, err
+	}
+	if post.Status != entity.PostPublished {
+		return http.StatusNotFound, errors.New("post not found")
+	}
+	if input.Vote == 0 {
+		// voting 0 again removes a dislike, which does not need the privilege
+		vote, status, err := s.postRepo.GetPostVote(ctx, input.PostID, input.UserID)
+		if err != nil && status != http.StatusNotFound {
+			return status, err
+		}
+		if err != nil || vote != 0 {
+			if status, err := s.reputation.require(ctx, input.UserID, privilegeDownvote, s.reputation.privileges.Downvote); err != nil {
+				return status, err
+			}
+		}
+	}
+	if status, err := s.postRepo.UpsertPostVote(ctx, input, s.reputation.weights.Post); err != nil {
+		return status, err
+	}
+	s.auditor.Record(ctx, input.UserID, entity.AuditPostVote, "post", input.PostID, strconv.Itoa(input.Vote))
+	return http.StatusOK, nil
 }
 
-func (s *PostService) GetAllByTag(ctx context.Context, tagName string) ([]entity.Post, int, error) {
+// AcceptAnswer marks a top-level comment of the post as its answer, or clears the
+// answer. Only the post author and moderators can accept answers, and the author can
+// not change the answer of a locked thread.
+func (s *PostService) AcceptAnswer(ctx context.Context, input entity.AcceptedAnswer, role entity.Role) (int, error) {
+	post, status, err := s.postRepo.GetPostStatus(ctx, input.PostID)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return status, errors.New("post not found")
+		}
+		return status, err
+	}
+	if post.Status != entity.PostPublished {
+		return http.StatusNotFound, errors.New("post not found")
+	}
+	moderator := role.Can(entity.PermModerate)
+	if post.UserID != input.UserID && !moderator {
+		return http.StatusForbidden, errors.New("only the author can accept an answer")
+	} else if post.Locked && !moderator {
+		return http.StatusForbidden, errors.New("thread is locked")
+	}
+	if input.CommentID != 0 {
+		comment, status, err := s.commentRepo.GetCommentByID(ctx, input.CommentID)
+		if err != nil {
+			if status == http.StatusNotFound {
+				return status, errors.New("comment not found")
+			}
+			return status, err
+		}
+		if comment.PostID != post.PostID {
+			return http.StatusBadRequest, errors.New("comment is not on this post")
+		} else if comment.ParentID != 0 {
+			return http.StatusBadRequest, errors.New("only top-level comments can be accepted")
+		}
+	}
+	if input.CommentID == post.AcceptedCommentID {
+		return http.StatusOK, nil
+	}
+	if status, err := s.postRepo.SetAcceptedAnswer(ctx, post.PostID, input.CommentID, s.reputation.weights.AcceptedAnswer); err != nil {
+		if status == http.StatusNotFound {
+			return status, errors.New("post not found")
+		}
+		return status, err
+	}
+	s.auditor.Record(ctx, input.UserID, entity.AuditAnswerAccept, "post", post.PostID, strconv.FormatUint(uint64(input.CommentID), 10))
+	return http.StatusOK, nil
+}
+
+// GetAllByTag returns a page of the posts of the tag in the order of sort. An empty
+// sort mode is the newest first order and an empty top window covers all posts.
+// Unanswered leaves out the posts with an accepted answer.
+func (s *PostService) GetAllByTag(ctx context.Context, tagName string, sort entity.PostSort, unanswered bool, cursor string, limit int) (entity.PostPage, int, error) {
 	if strings.TrimSpace(tagName) == "" {
-		return nil, http.StatusBadRequest, errors.New("invalid tag")
+		return entity.PostPage{}, http.StatusBadRequest, errors.New("invalid tag")
+	}
+	if sort.Mode == "" {
+		sort.Mode = entity.SortNew
 	}
-	return s.postRepo.GetAllByTag(ctx, tagName)
+	if sort.Mode == entity.SortTop && sort.Window == "" {
+		sort.Window = entity.WindowAll
+	}
+	if !sort.IsValid() {
+		return entity.PostPage{}, http.StatusBadRequest, errors.New("invalid sort")
+	}
+	start := entity.PostCursor{Sort: sort.String(), Time: time.Now().Unix()}
+	return getPostPage(cursor, limit, start, func(after entity.PostCursor, limit int) ([]entity.Post, int, error) {
+		return s.postRepo.GetAllByTag(ctx, tagName, sort, unanswered, after, limit)
+	})
+}
+
+func (s *PostService) GetAllByUserID(ctx context.Context, userID uint, cursor string, limit int) (entity.PostPage, int, error) {
+	return getPostPage(cursor, limit, entity.PostCursor{}, func(after entity.PostCursor, limit int) ([]entity.Post, int, error) {
+		return s.postRepo.GetAllByUserID(ctx, userID, after, limit)
+	})
 }
 
-func (s *PostService) GetAllByUserID(ctx context.Context, userID uint) ([]entity.Post, int, error) {
-	return s.postRepo.GetAllByUserID(ctx, userID)
+// GetDrafts returns a page of the drafts and scheduled posts of the user.
+func (s *PostService) GetDrafts(ctx context.Context, userID uint, cursor string, limit int) (entity.PostPage, int, error) {
+	return getPostPage(cursor, limit, entity.PostCursor{}, func(after entity.PostCursor, limit int) ([]entity.Post, int, error) {
+		return s.postRepo.GetDraftsByUserID(ctx, userID, after, limit)
+	})
 }
 
-func (s *PostService) GetAllLikedPostsByUserID(ctx context.Context, userID uint, islike bool) ([]entity.Post, int, error) {
-	return s.postRepo.GetAllLikedPostsByUserID(ctx, userID, islike)
+func (s *PostService) GetAllLikedPostsByUserID(ctx context.Context, userID uint, islike bool, cursor string, limit int) (entity.PostPage, int, error) {
+	return getPostPage(cursor, limit, entity.PostCursor{}, func(after entity.PostCursor, limit int) ([]entity.Post, int, error) {
+		return s.postRepo.GetAllLikedPostsByUserID(ctx, userID, islike, after, limit)
+	})
 }
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"forum/internal/entity"
+	"forum/internal/repository"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type ReportService struct {
+	reportRepo repository.Report
+	moderation *ModerationService
+	bans       *BanService
+}
+
+func newReportService(reportRepo repository.Report, moderation *ModerationService, bans *BanService) *ReportService {
+	return &ReportService{
+		reportRepo: reportRepo,
+		moderation: moderation,
+		bans:       bans,
+	}
+}
+
+// CreateReport files a report about a post, comment or user. Reports about the same
+// target are grouped into one open report.
+func (s *ReportService) CreateReport(ctx context.Context, input entity.ReportInput) (uint, int, error) {
+	if !entity.IsValidReportCategory(input.Category) {
+		return 0, http.StatusBadRequest, errors.New("invalid category")
+	}
+	input.Comment = strings.TrimSpace(input.Comment)
+	if len(input.Comment) > 500 {
+		return 0, http.StatusBadRequest, errors.New("comment is too long")
+	}
+	authorID, status, err := s.reportRepo.GetTargetAuthor(ctx, input.TargetType, input.TargetID)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return 0, status, errors.New(input.TargetType + " not found")
+		}
+		return 0, status, err
+	}
+	if authorID == input.ReporterID {
+		return 0, http.StatusBadRequest, errors.New("cannot report own content")
+	}
+	return s.reportRepo.CreateReport(ctx, input)
+}
+
+func (s *ReportService) GetOpenReports(ctx context.Context) ([]entity.Report, int, error) {
+	return s.reportRepo.GetOpenReports(ctx)
+}
+
+// ResolveReport closes an open report by dismissing it, removing the reported content,
+// warning the author or banning the author.
+func (s *ReportService) ResolveReport(ctx context.Context, moderatorID uint, input entity.ReportResolution) (int, error) {
+	if err := isValidReason(input.Reason); err != nil {
+		return http.StatusBadRequest, err
+	}
+	report, status, err := s.reportRepo.GetReportByID(ctx, input.ReportID)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return status, errors.New("report not found")
+		}
+		return status, err
+	}
+	if report.Status != entity.ReportOpen {
+		return http.StatusConflict, errors.New("report is already resolved")
+	}
+	if input.Action != "dismiss" && report.AuthorID == 0 {
+		return http.StatusGone, errors.New("reported " + report.TargetType + " no longer exists")
+	}
+
+	var resolution string
+	switch input.Action {
+	case "dismiss":
+		resolution = entity.ReportDismissed
+	case "remove":
+		resolution = entity.ReportRemoved
+		switch report.TargetType {
+		case "post":
+			status, err = s.moderation.DeletePost(ctx, moderatorID, report.TargetID, input.Reason)
+		case "comment":
+			status, err = s.moderation.DeleteComment(ctx, moderatorID, report.TargetID, input.Reason)
+		default:
+			return http.StatusBadRequest, errors.New("users cannot be removed, ban them instead")
+		}
+	case "warn":
+		resolution = entity.ReportWarned
+		status, err = s.moderation.WarnUser(ctx, moderatorID, report.AuthorID, input.Reason)
+	case "ban":
+		resolution = entity.ReportBanned
+		ban := entity.Ban{UserID: report.AuthorID, Reason: input.Reason}
+		if input.Duration != "" {
+			duration, err := time.ParseDuration(input.Duration)
+			if err != nil {
+				return http.StatusBadRequest, errors.New("invalid duration")
+			}
+			expiresAt := time.Now().Add(duration)
+			ban.ExpiresAt = &expiresAt
+		}
+		_, status, err = s.bans.BanUser(ctx, moderatorID, ban)
+	default:
+		return http.StatusBadRequest, errors.New("invalid action")
+	}
+	if err != nil {
+		return status, err
+	}
+	if status, err := s.reportRepo.ResolveReport(ctx, report.ID, moderatorID, resolution, strings.TrimSpace(input.Reason)); err != nil {
+		if status == http.StatusNotFound {
+			return http.StatusConflict, errors.New("report is already resolved")
+		}
+		return status, err
+	}
+	return http.StatusOK, nil
+}
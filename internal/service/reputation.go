@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"forum/internal/entity"
+	"forum/internal/repository"
+	"forum/pkg/config"
+	"forum/pkg/markdown"
+	"net/http"
+)
+
+// The privileges that users unlock with reputation, as they are named in errors.
+const (
+	privilegeDownvote    = "downvote"
+	privilegeCreateTag   = "create new tags"
+	privilegePostLinks   = "post links"
+	privilegeRetagOthers = "edit the tags of posts of other users"
+)
+
+type ReputationService struct {
+	userRepo   repository.User
+	weights    entity.ReputationWeights
+	privileges config.Privileges
+}
+
+func newReputationService(userRepo repository.User, weights entity.ReputationWeights, privileges config.Privileges) *ReputationService {
+	return &ReputationService{
+		userRepo:   userRepo,
+		weights:    weights,
+		privileges: privileges,
+	}
+}
+
+// reputationWeights returns the reputation weights of the config.
+func reputationWeights(cfg config.Reputation) entity.ReputationWeights {
+	return entity.ReputationWeights{
+		Post:           entity.VoteWeights{Like: cfg.PostLike, Dislike: cfg.PostDislike},
+		Comment:        entity.VoteWeights{Like: cfg.CommentLike, Dislike: cfg.CommentDislike},
+		AcceptedAnswer: cfg.AcceptedAnswer,
+	}
+}
+
+// Rebuild recomputes the reputation of all users with the current weights, for example
+// after the weights changed or posts and comments were deleted with their votes. It
+// returns the number of users whose reputation changed.
+func (s *ReputationService) Rebuild(ctx context.Context) (int64, int, error) {
+	return s.userRepo.RebuildReputation(ctx, s.weights)
+}
+
+// require returns a 403 error that names the privilege when the reputation of the user
+// is below its threshold. Moderators have all privileges, so that a new forum can get
+// its first tags.
+func (s *ReputationService) require(ctx context.Context, userID uint, privilege string, threshold int) (int, error) {
+	if threshold <= 0 {
+		return http.StatusOK, nil
+	}
+	user, status, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return status, err
+	}
+	if user.Reputation < threshold && !user.Role.Can(entity.PermModerate) {
+		return http.StatusForbidden, fmt.Errorf("you need %d reputation to %s, you have %d", threshold, privilege, user.Reputation)
+	}
+	return http.StatusOK, nil
+}
+
+// checkLinks checks that the user has the privilege to post links when the Markdown
+// has links or images that the old version of the text did not have. New posts and
+// comments have an empty old version.
+func (s *ReputationService) checkLinks(ctx context.Context, userID uint, data, old string) (int, error) {
+	links := markdown.Links(data)
+	if len(links) == 0 {
+		return http.StatusOK, nil
+	}
+	known := make(map[string]bool)
+	for _, link := range markdown.Links(old) {
+		known[link] = true
+	}
+	for _, link := range links {
+		if !known[link] {
+			return s.require(ctx, userID, privilegePostLinks, s.privileges.PostLinks)
+		}
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"forum/internal/entity"
+	"forum/internal/repository"
+	"html"
+	"net/http"
+	"strings"
+	"unicode"
+)
+
+type SearchService struct {
+	searchRepo repository.Search
+}
+
+func newSearchService(searchRepo repository.Search) *SearchService {
+	return &SearchService{searchRepo: searchRepo}
+}
+
+// Search returns a page of the posts and comments that contain all terms of the query,
+// best match first. Text in double quotes is searched as a phrase.
+func (s *SearchService) Search(ctx context.Context, query entity.SearchQuery) (entity.SearchPage, int, error) {
+	if len(query.Query) > 200 {
+		return entity.SearchPage{}, http.StatusBadRequest, errors.New("search query is too long")
+	}
+	match := matchQuery(query.Query)
+	if match == "" {
+		return entity.SearchPage{}, http.StatusBadRequest, errors.New("empty search query")
+	}
+	limit, err := pageLimit(query.Limit)
+	if err != nil {
+		return entity.SearchPage{}, http.StatusBadRequest, err
+	}
+	filter := entity.SearchFilter{
+		Match:  match,
+		Tag:    strings.TrimSpace(query.Tag),
+		Author: strings.TrimSpace(query.Author),
+		Limit:  limit + 1,
+	}
+	if query.Cursor != "" {
+		if err := decodeCursor(query.Cursor, &filter.After); err != nil || filter.After.Type == "" {
+			return entity.SearchPage{}, http.StatusBadRequest, errInvalidCursor
+		}
+	}
+	results, status, err := s.searchRepo.Search(ctx, filter)
+	if err != nil {
+		return entity.SearchPage{}, status, err
+	}
+	page := entity.SearchPage{Results: results}
+	if len(results) > limit {
+		page.Results = results[:limit]
+		last := page.Results[limit-1]
+		next := entity.SearchCursor{Rank: last.Rank, Type: last.Type, ID: last.PostID}
+		if last.Type == entity.SearchComment {
+			next.ID = last.CommentID
+		}
+		if page.NextCursor, err = encodeCursor(next); err != nil {
+			return entity.SearchPage{}, http.StatusInternalServerError, err
+		}
+	}
+	for i := range page.Results {
+		page.Results[i].Snippet = highlight(page.Results[i].Snippet)
+	}
+	return page, http.StatusOK, nil
+}
+
+// matchQuery turns the user query into an FTS5 query in which every word and every
+// quoted phrase is a string, so that the FTS5 operators in the input have no effect.
+func matchQuery(query string) string {
+	var terms []string
+	for i, part := range strings.Split(query, `"`) {
+		if i%2 == 1 {
+			// quoted phrase
+			if phrase := strings.Join(strings.FieldsFunc(part, isSeparator), " "); phrase != "" {
+				terms = append(terms, quoteTerm(phrase))
+			}
+			continue
+		}
+		for _, word := range strings.FieldsFunc(part, isSeparator) {
+			terms = append(terms, quoteTerm(word))
+		}
+	}
+	return strings.Join(terms, " ")
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+func quoteTerm(term string) string {
+	return `"` + term + `"`
+}
+
+// highlight escapes the snippet and replaces the match markers of the repository with
+// <mark> tags.
+func highlight(snippet string) string {
+	snippet = html.EscapeString(snippet)
+	snippet = strings.ReplaceAll(snippet, "\x02", "<mark>")
+	return strings.ReplaceAll(snippet, "\x03", "</mark>")
+}
@@ -4,34 +4,98 @@ import (
 	"context"
 	"forum/internal/entity"
 	"forum/internal/repository"
+	"forum/pkg/blobstore"
+	"forum/pkg/config"
+	smpljwt "forum/pkg/smplJwt"
+	"io"
 )
 
 type User interface {
 	Create(ctx context.Context, user entity.User) (int, error)
-	SignIn(ctx context.Context, user entity.User) (string, int, error)
+	CreateWithRole(ctx context.Context, user entity.User, role entity.Role) (int, error)
+	SignIn(ctx context.Context, user entity.User) (entity.TokenPair, int, error)
 	GetUserByID(ctx context.Context, userID uint) (entity.User, int, error)
+	ResetPassword(ctx context.Context, email string, password string) (int, error)
+	GetRoleByUserID(ctx context.Context, userID uint) (entity.Role, int, error)
+	GetAllUsers(ctx context.Context) ([]entity.User, int, error)
+	SetRole(ctx context.Context, actorID uint, userID uint, role entity.Role) (int, error)
 }
 
 type Session interface {
 	IsTokenExist(ctx context.Context, token string) (bool, error)
-	DeleteSessionByToken(ctx context.Context, token string) error
+	SignOut(ctx context.Context, userID uint, token string) error
 	DeleteSessionByUserID(ctx context.Context, userID uint) error
+	Refresh(ctx context.Context, refreshToken string) (entity.TokenPair, int, error)
+	DeleteUserSession(ctx context.Context, userID uint, sessionID string) (int, error)
+	GetSessionsByUserID(ctx context.Context, userID uint) ([]entity.Session, int, error)
+	TouchSession(ctx context.Context, token string) error
 }
 
 type Post interface {
 	CreatePost(ctx context.Context, input entity.Post) (uint, int, error)
 	DeletePostByID(ctx context.Context, postID uint, userID uint) (int, error)
 	UpsertPostVote(ctx context.Context, input entity.PostVote) (int, error)
+	AcceptAnswer(ctx context.Context, input entity.AcceptedAnswer, role entity.Role) (int, error)
+	UpdatePost(ctx context.Context, input entity.Post) (int, error)
+	RetagPost(ctx context.Context, input entity.Post) (int, error)
+	GetPostRevisions(ctx context.Context, postID uint, cursor string, limit int) (entity.PostRevisionPage, int, error)
 	GetPostByID(ctx context.Context, postID uint) (entity.Post, int, error)
-	GetAllByTag(ctx context.Context, tagName string) ([]entity.Post, int, error)
-	GetAllByUserID(ctx context.Context, userID uint) ([]entity.Post, int, error)
-	GetAllLikedPostsByUserID(ctx context.Context, userID uint, islike bool) ([]entity.Post, int, error)
+	GetDraft(ctx context.Context, postID uint, userID uint) (entity.Post, int, error)
+	GetDrafts(ctx context.Context, userID uint, cursor string, limit int) (entity.PostPage, int, error)
+	PublishDuePosts(ctx context.Context) ([]entity.Post, int, error)
+	GetAllByTag(ctx context.Context, tagName string, sort entity.PostSort, unanswered bool, cursor string, limit int) (entity.PostPage, int, error)
+	GetAllByUserID(ctx context.Context, userID uint, cursor string, limit int) (entity.PostPage, int, error)
+	GetAllLikedPostsByUserID(ctx context.Context, userID uint, islike bool, cursor string, limit int) (entity.PostPage, int, error)
 }
 
 type Comment interface {
 	CreateComment(ctx context.Context, input entity.Comment) (int, error)
 	DeleteComment(ctx context.Context, commentID uint, userID uint) (int, error)
 	UpsertCommentVote(ctx context.Context, input entity.CommentVote) (int, error)
+	UpdateComment(ctx context.Context, input entity.Comment, role entity.Role) (int, error)
+	GetCommentRevisions(ctx context.Context, commentID uint, cursor string, limit int) (entity.CommentRevisionPage, int, error)
+}
+
+type Moderation interface {
+	DeletePost(ctx context.Context, moderatorID uint, postID uint, reason string) (int, error)
+	DeleteComment(ctx context.Context, moderatorID uint, commentID uint, reason string) (int, error)
+	LockPost(ctx context.Context, moderatorID uint, postID uint, locked bool, reason string) (int, error)
+	PinPost(ctx context.Context, moderatorID uint, postID uint, pinned bool, reason string) (int, error)
+	GetActions(ctx context.Context) ([]entity.ModerationAction, int, error)
+}
+
+type Audit interface {
+	GetAuditLog(ctx context.Context, filter entity.AuditFilter) ([]entity.AuditEntry, int, error)
+}
+
+type Ban interface {
+	BanUser(ctx context.Context, adminID uint, ban entity.Ban) (uint, int, error)
+	LiftBan(ctx context.Context, adminID uint, banID uint) (int, error)
+	GetActiveBans(ctx context.Context) ([]entity.Ban, int, error)
+	CheckBan(ctx context.Context, userID uint) (int, error)
+}
+
+type Report interface {
+	CreateReport(ctx context.Context, input entity.ReportInput) (uint, int, error)
+	GetOpenReports(ctx context.Context) ([]entity.Report, int, error)
+	ResolveReport(ctx context.Context, moderatorID uint, input entity.ReportResolution) (int, error)
+}
+
+type Search interface {
+	Search(ctx context.Context, query entity.SearchQuery) (entity.SearchPage, int, error)
+}
+
+type Attachment interface {
+	Upload(ctx context.Context, upload entity.Upload) (entity.Attachment, int, error)
+	Open(ctx context.Context, id uint, thumbnail bool) (entity.Attachment, io.ReadCloser, int, error)
+}
+
+type Poll interface {
+	Vote(ctx context.Context, input entity.PollVote) (entity.Poll, int, error)
+}
+
+type Reputation interface {
+	Rebuild(ctx context.Context) (int64, int, error)
 }
 
 type Service struct {
@@ -39,13 +103,42 @@ type Service struct {
 	Session
 	Post
 	Comment
+	Moderation
+	Audit
+	Ban
+	Report
+	Search
+	Attachment
+	Poll
+	Reputation
 }
 
-func NewService(repo *repository.Repository, secret string) *Service {
+// NewService wires the services. The blob store is only used for attachments and can
+// be nil for commands that do not touch them.
+func NewService(repo *repository.Repository, keys *smpljwt.KeySet, cfg *config.Conf, store blobstore.BlobStore) *Service {
+	issuer := &tokenIssuer{
+		keys:       keys,
+		accessTTL:  cfg.JWT.TokenTTL.Duration,
+		refreshTTL: cfg.JWT.RefreshTokenTTL.Duration,
+	}
+	auditor := newAuditor(repo.Audit)
+	attachments := newAttachmentService(repo.Attachment, repo.Post, store, cfg.Uploads)
+	moderation := newModerationService(repo.Moderation, repo.Post, repo.Comment, auditor, attachments)
+	bans := newBanService(repo.Ban, repo.User, repo.Session, auditor)
+	polls := newPollService(repo.Poll, repo.Post, auditor)
+	reputation := newReputationService(repo.User, reputationWeights(cfg.Reputation), cfg.Privileges)
 	return &Service{
-		User:    newUserService(repo.User, repo.Session, secret),
-		Session: newSessionService(repo.Session),
-		Post:    newPostService(repo.Post, repo.Tag),
-		Comment: newCommentService(repo.Comment),
+		User:       newUserService(repo.User, repo.Session, repo.Ban, issuer, auditor),
+		Session:    newSessionService(repo.Session, issuer, auditor),
+		Post:       newPostService(repo.Post, repo.Tag, repo.Comment, auditor, attachments, polls, reputation),
+		Comment:    newCommentService(repo.Comment, repo.Post, auditor, cfg.Comments.MaxDepth, reputation),
+		Moderation: moderation,
+		Audit:      auditor,
+		Ban:        bans,
+		Report:     newReportService(repo.Report, moderation, bans),
+		Search:     newSearchService(repo.Search),
+		Attachment: attachments,
+		Poll:       polls,
+		Reputation: reputation,
 	}
 }
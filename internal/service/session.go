@@ -2,25 +2,168 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"forum/internal/entity"
 	"forum/internal/repository"
+	smpljwt "forum/pkg/smplJwt"
+	"net/http"
+	"time"
 )
 
 type SessionService struct {
 	sessionRepo repository.Session
+	issuer      *tokenIssuer
+	auditor     *Auditor
 }
 
-func newSessionService(sessionRepo repository.Session) *SessionService {
-	return &SessionService{sessionRepo: sessionRepo}
+func newSessionService(sessionRepo repository.Session, issuer *tokenIssuer, auditor *Auditor) *SessionService {
+	return &SessionService{
+		sessionRepo: sessionRepo,
+		issuer:      issuer,
+		auditor:     auditor,
+	}
 }
 
 func (s *SessionService) IsTokenExist(ctx context.Context, token string) (bool, error) {
 	return s.sessionRepo.IsTokenExist(ctx, token)
 }
 
-func (s *SessionService) DeleteSessionByToken(ctx context.Context, token string) error {
-	return s.sessionRepo.DeleteSessionByToken(ctx, token)
+func (s *SessionService) SignOut(ctx context.Context, userID uint, token string) error {
+	if err := s.sessionRepo.DeleteSessionByToken(ctx, token); err != nil {
+		return err
+	}
+	s.auditor.Record(ctx, userID, entity.AuditSignOut, "user", userID, "")
+	return nil
 }
 
 func (s *SessionService) DeleteSessionByUserID(ctx context.Context, userID uint) error {
-	return s.sessionRepo.DeleteSessionByUserID(ctx, userID)
+	if err := s.sessionRepo.DeleteSessionByUserID(ctx, userID); err != nil {
+		return err
+	}
+	s.auditor.Record(ctx, userID, entity.AuditSignOutAll, "user", userID, "")
+	return nil
+}
+
+func (s *SessionService) DeleteUserSession(ctx context.Context, userID uint, sessionID string) (int, error) {
+	if sessionID == "" {
+		return http.StatusBadRequest, errors.New("invalid session id")
+	}
+	status, err := s.sessionRepo.DeleteUserSession(ctx, userID, sessionID)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return status, errors.New("session not found")
+		}
+		return status, err
+	}
+	s.auditor.Record(ctx, userID, entity.AuditSessionRevoke, "session", 0, sessionID)
+	return http.StatusOK, nil
+}
+
+func (s *SessionService) GetSessionsByUserID(ctx context.Context, userID uint) ([]entity.Session, int, error) {
+	return s.sessionRepo.GetSessionsByUserID(ctx, userID)
+}
+
+func (s *SessionService) TouchSession(ctx context.Context, token string) error {
+	return s.sessionRepo.TouchSession(ctx, token, clientFromContext(ctx))
+}
+
+// Refresh exchanges a refresh token for a new token pair. Presenting a refresh token
+// that was already exchanged revokes the whole token family.
+func (s *SessionService) Refresh(ctx context.Context, refreshToken string) (entity.TokenPair, int, error) {
+	if refreshToken == "" {
+		return entity.TokenPair{}, http.StatusBadRequest, errors.New("empty refresh token")
+	}
+	session, status, err := s.sessionRepo.GetSessionByRefreshHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if status == http.StatusUnauthorized {
+			return entity.TokenPair{}, status, errors.New("invalid refresh token")
+		}
+		return entity.TokenPair{}, status, err
+	}
+	if session.Rotated {
+		s.auditor.Record(ctx, session.UserID, entity.AuditRefreshReuse, "session", 0, session.Family)
+		if err := s.sessionRepo.DeleteSessionByFamily(ctx, session.Family); err != nil {
+			return entity.TokenPair{}, http.StatusInternalServerError, err
+		}
+		return entity.TokenPair{}, http.StatusUnauthorized, errors.New("invalid refresh token")
+	}
+	if time.Now().After(session.ExpiresAt) {
+		if err := s.sessionRepo.DeleteSessionByFamily(ctx, session.Family); err != nil {
+			return entity.TokenPair{}, http.StatusInternalServerError, err
+		}
+		return entity.TokenPair{}, http.StatusUnauthorized, errors.New("refresh token is expired")
+	}
+	next, tokens, err := s.issuer.issue(session.UserID, session.Family, clientFromContext(ctx))
+	if err != nil {
+		return entity.TokenPair{}, http.StatusInternalServerError, err
+	}
+	if status, err := s.sessionRepo.RotateSession(ctx, session.ID, next); err != nil {
+		if err == repository.ErrSessionRotated {
+			if err := s.sessionRepo.DeleteSessionByFamily(ctx, session.Family); err != nil {
+				return entity.TokenPair{}, http.StatusInternalServerError, err
+			}
+			return entity.TokenPair{}, status, errors.New("invalid refresh token")
+		}
+		return entity.TokenPair{}, status, err
+	}
+	return tokens, http.StatusOK, nil
+}
+
+// tokenIssuer creates short-lived access tokens and long-lived refresh tokens.
+type tokenIssuer struct {
+	keys       *smpljwt.KeySet
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+// issue returns a token pair and the session row that stores it. An empty family
+// starts a new token family.
+func (t *tokenIssuer) issue(userID uint, family string, client entity.Client) (entity.Session, entity.TokenPair, error) {
+	var err error
+	if family == "" {
+		if family, err = randomToken(16); err != nil {
+			return entity.Session{}, entity.TokenPair{}, err
+		}
+	}
+	access, err := smpljwt.NewJWT(userID, t.keys, t.accessTTL)
+	if err != nil {
+		return entity.Session{}, entity.TokenPair{}, err
+	}
+	refresh, err := randomToken(32)
+	if err != nil {
+		return entity.Session{}, entity.TokenPair{}, err
+	}
+	session := entity.Session{
+		Family:      family,
+		UserID:      userID,
+		Token:       access,
+		RefreshHash: hashToken(refresh),
+		ExpiresAt:   time.Now().Add(t.refreshTTL),
+		IP:          client.IP,
+		UserAgent:   client.UserAgent,
+	}
+	return session, entity.TokenPair{AccessToken: access, RefreshToken: refresh}, nil
+}
+
+func randomToken(size int) (string, error) {
+	data := make([]byte, size)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// clientFromContext returns the client that the http layer stored under "client".
+func clientFromContext(ctx context.Context) entity.Client {
+	client, _ := ctx.Value("client").(entity.Client)
+	return client
 }
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"forum/internal/entity"
 	"forum/internal/repository"
-	smpljwt "forum/pkg/smplJwt"
 	"forum/pkg/utils"
 	"net/http"
 )
@@ -13,64 +12,144 @@ import (
 type UserService struct {
 	userRepo    repository.User
 	sessionRepo repository.Session
-	secret      string
+	banRepo     repository.Ban
+	issuer      *tokenIssuer
+	auditor     *Auditor
 }
 
-func newUserService(userRepo repository.User, sessionRepo repository.Session, secret string) *UserService {
+func newUserService(userRepo repository.User, sessionRepo repository.Session, banRepo repository.Ban, issuer *tokenIssuer, auditor *Auditor) *UserService {
 	return &UserService{
 		userRepo:    userRepo,
 		sessionRepo: sessionRepo,
-		secret:      secret,
+		banRepo:     banRepo,
+		issuer:      issuer,
+		auditor:     auditor,
 	}
 }
 
 func (s *UserService) Create(ctx context.Context, user entity.User) (int, error) {
+	return s.CreateWithRole(ctx, user, entity.RoleUser)
+}
+
+// CreateWithRole creates a user that has the role from the start, so that a failure
+// never leaves the user behind with another role. Other roles than the user role are
+// recorded like a role change.
+func (s *UserService) CreateWithRole(ctx context.Context, user entity.User, role entity.Role) (int, error) {
+	if !role.IsValid() {
+		return http.StatusBadRequest, errors.New("invalid role")
+	}
 	if err := utils.IsValidRegister(&user); err != nil {
 		return http.StatusBadRequest, err
 	}
+	user.Role = role
 
-	status, err := s.userRepo.Create(ctx, user)
-	if status == http.StatusBadRequest {
-		switch err.Error() {
-		case "UNIQUE constraint failed: users.email":
-			return status, errors.New("already email is using")
-		case "UNIQUE constraint failed: users.username":
-			return status, errors.New("already username is using")
+	id, status, err := s.userRepo.Create(ctx, user)
+	if err != nil {
+		if status == http.StatusBadRequest {
+			switch err.Error() {
+			case "UNIQUE constraint failed: users.email":
+				return status, errors.New("already email is using")
+			case "UNIQUE constraint failed: users.username":
+				return status, errors.New("already username is using")
+			}
 		}
+		return status, err
 	}
-	return status, err
+	if role != entity.RoleUser {
+		s.auditor.Record(ctx, 0, entity.AuditRoleChange, "user", id, string(role))
+	}
+	return status, nil
 }
 
-func (s *UserService) SignIn(ctx context.Context, user entity.User) (string, int, error) {
+func (s *UserService) SignIn(ctx context.Context, user entity.User) (entity.TokenPair, int, error) {
 	if user.Email == "" {
-		return "", http.StatusBadRequest, errors.New("invalid email")
+		return entity.TokenPair{}, http.StatusBadRequest, errors.New("invalid email")
 	} else if user.Password == "" {
-		return "", http.StatusBadRequest, errors.New("invalid password")
+		return entity.TokenPair{}, http.StatusBadRequest, errors.New("invalid password")
 	}
 	repoUserStruct, status, err := s.userRepo.GetUserIDByEmail(ctx, user.Email)
 	if err != nil {
 		if status == http.StatusBadRequest {
-			return "", status, errors.New("invalid email or password")
+			s.auditor.Record(ctx, 0, entity.AuditSignInFailed, "user", 0, user.Email)
+			return entity.TokenPair{}, status, errors.New("invalid email or password")
 		}
-		return "", status, err
+		return entity.TokenPair{}, status, err
 	}
 	if err := utils.CompareHashAndPassword(repoUserStruct.Password, user.Password); err != nil {
-		return "", http.StatusBadRequest, errors.New("invalid password")
+		s.auditor.Record(ctx, 0, entity.AuditSignInFailed, "user", repoUserStruct.ID, user.Email)
+		return entity.TokenPair{}, http.StatusBadRequest, errors.New("invalid password")
+	}
+	if status, err := checkBan(ctx, s.banRepo, repoUserStruct.ID); err != nil {
+		if status == http.StatusForbidden {
+			s.auditor.Record(ctx, 0, entity.AuditSignInFailed, "user", repoUserStruct.ID, err.Error())
+		}
+		return entity.TokenPair{}, status, err
 	}
-	token, err := smpljwt.NewJWT(repoUserStruct.ID, s.secret)
+	session, tokens, err := s.issuer.issue(repoUserStruct.ID, "", clientFromContext(ctx))
 	if err != nil {
-		return "", http.StatusInternalServerError, err
+		return entity.TokenPair{}, http.StatusInternalServerError, err
 	}
 
-	if status, err = s.sessionRepo.PostSession(ctx, entity.Session{
-		UserID: repoUserStruct.ID,
-		Token:  token,
-	}); err != nil {
-		return "", status, err
+	if status, err = s.sessionRepo.PostSession(ctx, session); err != nil {
+		return entity.TokenPair{}, status, err
 	}
-	return token, http.StatusOK, nil
+	s.auditor.Record(ctx, repoUserStruct.ID, entity.AuditSignIn, "session", 0, session.Family)
+	return tokens, http.StatusOK, nil
 }
 
 func (s *UserService) GetUserByID(ctx context.Context, userID uint) (entity.User, int, error) {
 	return s.userRepo.GetUserByID(ctx, userID)
 }
+
+func (s *UserService) ResetPassword(ctx context.Context, email string, password string) (int, error) {
+	user, status, err := s.userRepo.GetUserIDByEmail(ctx, email)
+	if err != nil {
+		if status == http.StatusBadRequest {
+			return http.StatusNotFound, errors.New("user not found")
+		}
+		return status, err
+	}
+	hashPass, err := utils.NewHashPassword(password)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	if status, err := s.userRepo.UpdatePassword(ctx, user.ID, hashPass); err != nil {
+		return status, err
+	}
+	if err := s.sessionRepo.DeleteSessionByUserID(ctx, user.ID); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	s.auditor.Record(ctx, 0, entity.AuditPasswordReset, "user", user.ID, "")
+	return http.StatusOK, nil
+}
+
+func (s *UserService) GetRoleByUserID(ctx context.Context, userID uint) (entity.Role, int, error) {
+	user, status, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return "", http.StatusUnauthorized, errors.New("user not found")
+		}
+		return "", status, err
+	}
+	return user.Role, http.StatusOK, nil
+}
+
+func (s *UserService) GetAllUsers(ctx context.Context) ([]entity.User, int, error) {
+	return s.userRepo.GetAllUsers(ctx)
+}
+
+// SetRole changes the role of the user; actorID is 0 when the change comes from the cli.
+func (s *UserService) SetRole(ctx context.Context, actorID uint, userID uint, role entity.Role) (int, error) {
+	if !role.IsValid() {
+		return http.StatusBadRequest, errors.New("invalid role")
+	}
+	status, err := s.userRepo.UpdateRole(ctx, userID, role)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return status, errors.New("user not found")
+		}
+		return status, err
+	}
+	s.auditor.Record(ctx, actorID, entity.AuditRoleChange, "user", userID, string(role))
+	return http.StatusOK, nil
+}
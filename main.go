@@ -1,15 +1,56 @@
+// Command forum runs the forum server and its maintenance commands. Full-text search
+// needs the FTS5 module of SQLite, so the binary is built with the sqlite_fts5 tag:
+//
+//	go build -tags sqlite_fts5 -o forum .
 package main
 
 import (
+	"fmt"
 	"forum/internal/app"
 	"forum/pkg/config"
 	"log"
+	"os"
 )
 
+const usage = `usage: forum <command> [arguments]
+
+build with: go build -tags sqlite_fts5 -o forum .
+
+commands:
+  serve                              run the http server (default)
+  migrate up|down [-steps N]|status  manage the database schema
+  user create -email -username [-password] [-role]
+  user reset-password -email [-password]
+  user set-role -email -role
+  reputation rebuild                 recompute the reputation of all users from votes
+  backup <file>                      write a copy of the database to file
+`
+
 func main() {
 	cfg, err := config.NewConfig()
 	if err != nil {
 		log.Fatalf("Config error: %s", err)
 	}
-	app.Run(cfg)
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+	switch command {
+	case "serve":
+		app.Run(cfg)
+	case "migrate":
+		err = app.Migrate(cfg, args)
+	case "user":
+		err = app.User(cfg, args)
+	case "reputation":
+		err = app.Reputation(cfg, args)
+	case "backup":
+		err = app.Backup(cfg, args)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatalf("%s error: %s", command, err)
+	}
 }
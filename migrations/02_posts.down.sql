@@ -0,0 +1 @@
+DROP TABLE IF EXISTS post;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS post_vote;
\ No newline at end of file
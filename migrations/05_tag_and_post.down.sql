@@ -0,0 +1 @@
+DROP TABLE IF EXISTS tag_and_post;
\ No newline at end of file
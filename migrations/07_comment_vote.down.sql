@@ -0,0 +1 @@
+DROP TABLE IF EXISTS comment_vote;
\ No newline at end of file
@@ -0,0 +1 @@
+DELETE FROM tags WHERE name = "ALL";
\ No newline at end of file
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS sessions;
+CREATE TABLE sessions(
+    token TEXT NOT NULL,
+    user_id INTEGER UNIQUE NOT NULL,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS sessions;
+CREATE TABLE sessions(
+    id INTEGER PRIMARY KEY AUTOINCREMENT UNIQUE,
+    family TEXT NOT NULL,
+    user_id INTEGER NOT NULL,
+    token TEXT NOT NULL,
+    refresh_hash TEXT UNIQUE NOT NULL,
+    expires_at DATETIME NOT NULL,
+    rotated INTEGER NOT NULL DEFAULT 0,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX sessions_token ON sessions(token);
+CREATE INDEX sessions_family ON sessions(family);
\ No newline at end of file
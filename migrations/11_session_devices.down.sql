@@ -0,0 +1,16 @@
+CREATE TABLE sessions_old(
+    id INTEGER PRIMARY KEY AUTOINCREMENT UNIQUE,
+    family TEXT NOT NULL,
+    user_id INTEGER NOT NULL,
+    token TEXT NOT NULL,
+    refresh_hash TEXT UNIQUE NOT NULL,
+    expires_at DATETIME NOT NULL,
+    rotated INTEGER NOT NULL DEFAULT 0,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+INSERT INTO sessions_old(id, family, user_id, token, refresh_hash, expires_at, rotated)
+    SELECT id, family, user_id, token, refresh_hash, expires_at, rotated FROM sessions;
+DROP TABLE sessions;
+ALTER TABLE sessions_old RENAME TO sessions;
+CREATE INDEX sessions_token ON sessions(token);
+CREATE INDEX sessions_family ON sessions(family);
\ No newline at end of file
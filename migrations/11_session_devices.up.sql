@@ -0,0 +1,21 @@
+CREATE TABLE sessions_new(
+    id INTEGER PRIMARY KEY AUTOINCREMENT UNIQUE,
+    family TEXT NOT NULL,
+    user_id INTEGER NOT NULL,
+    token TEXT NOT NULL,
+    refresh_hash TEXT UNIQUE NOT NULL,
+    expires_at DATETIME NOT NULL,
+    rotated INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_seen DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ip TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+INSERT INTO sessions_new(id, family, user_id, token, refresh_hash, expires_at, rotated)
+    SELECT id, family, user_id, token, refresh_hash, expires_at, rotated FROM sessions;
+DROP TABLE sessions;
+ALTER TABLE sessions_new RENAME TO sessions;
+CREATE INDEX sessions_token ON sessions(token);
+CREATE INDEX sessions_family ON sessions(family);
+CREATE INDEX sessions_user_id ON sessions(user_id);
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS moderation_log;
+ALTER TABLE post DROP COLUMN pinned;
+ALTER TABLE post DROP COLUMN locked;
\ No newline at end of file
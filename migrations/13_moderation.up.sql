@@ -0,0 +1,12 @@
+ALTER TABLE post ADD COLUMN locked INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE post ADD COLUMN pinned INTEGER NOT NULL DEFAULT 0;
+CREATE TABLE IF NOT EXISTS moderation_log(
+    id INTEGER PRIMARY KEY AUTOINCREMENT UNIQUE,
+    moderator_id INTEGER,
+    action TEXT NOT NULL,
+    target_type TEXT NOT NULL,
+    target_id INTEGER NOT NULL,
+    reason TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(moderator_id) REFERENCES users(id) ON DELETE SET NULL
+);
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS audit_log(
+    id INTEGER PRIMARY KEY AUTOINCREMENT UNIQUE,
+    actor_id INTEGER,
+    action TEXT NOT NULL,
+    target_type TEXT NOT NULL DEFAULT '',
+    target_id INTEGER,
+    ip TEXT NOT NULL DEFAULT '',
+    detail TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX audit_log_actor ON audit_log(actor_id, created_at);
+CREATE INDEX audit_log_action ON audit_log(action, created_at);
\ No newline at end of file
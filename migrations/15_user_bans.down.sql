@@ -0,0 +1 @@
+DROP TABLE IF EXISTS bans;
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS bans(
+    id INTEGER PRIMARY KEY AUTOINCREMENT UNIQUE,
+    user_id INTEGER NOT NULL,
+    admin_id INTEGER,
+    reason TEXT NOT NULL,
+    expires_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    lifted_at DATETIME,
+    lifted_by INTEGER,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY(admin_id) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY(lifted_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX bans_user_id ON bans(user_id, lifted_at);
\ No newline at end of file
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS reports(
+    id INTEGER PRIMARY KEY AUTOINCREMENT UNIQUE,
+    target_type TEXT NOT NULL CHECK(target_type IN ('post', 'comment', 'user')),
+    target_id INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'open' CHECK(status IN ('open', 'dismissed', 'removed', 'warned', 'banned')),
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    resolved_by INTEGER,
+    resolved_at DATETIME,
+    note TEXT NOT NULL DEFAULT '',
+    FOREIGN KEY(resolved_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE UNIQUE INDEX reports_open_target ON reports(target_type, target_id) WHERE status = 'open';
+CREATE TABLE IF NOT EXISTS report_entries(
+    id INTEGER PRIMARY KEY AUTOINCREMENT UNIQUE,
+    report_id INTEGER NOT NULL,
+    reporter_id INTEGER NOT NULL,
+    category TEXT NOT NULL CHECK(category IN ('spam', 'abuse', 'off_topic', 'other')),
+    comment TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(report_id) REFERENCES reports(id) ON DELETE CASCADE,
+    FOREIGN KEY(reporter_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE(report_id, reporter_id)
+);
\ No newline at end of file
@@ -0,0 +1,13 @@
+ALTER TABLE post ADD COLUMN edited_at DATETIME;
+CREATE TABLE IF NOT EXISTS post_revision(
+    id INTEGER PRIMARY KEY AUTOINCREMENT UNIQUE,
+    post_id INTEGER NOT NULL,
+    editor_id INTEGER,
+    title TEXT NOT NULL,
+    data TEXT NOT NULL,
+    tags TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(post_id) REFERENCES post(id) ON DELETE CASCADE,
+    FOREIGN KEY(editor_id) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX post_revision_post_id ON post_revision(post_id);
\ No newline at end of file
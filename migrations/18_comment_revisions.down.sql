@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS comment_revision;
+ALTER TABLE comment DROP COLUMN edited_at;
\ No newline at end of file
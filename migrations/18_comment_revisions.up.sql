@@ -0,0 +1,11 @@
+ALTER TABLE comment ADD COLUMN edited_at DATETIME;
+CREATE TABLE IF NOT EXISTS comment_revision(
+    id INTEGER PRIMARY KEY AUTOINCREMENT UNIQUE,
+    comment_id INTEGER NOT NULL,
+    editor_id INTEGER,
+    data TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(comment_id) REFERENCES comment(id) ON DELETE CASCADE,
+    FOREIGN KEY(editor_id) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX comment_revision_comment_id ON comment_revision(comment_id);
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS comment_parent_id;
+ALTER TABLE comment DROP COLUMN parent_id;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE comment ADD COLUMN parent_id INTEGER REFERENCES comment(id) ON DELETE CASCADE;
+CREATE INDEX comment_parent_id ON comment(parent_id);
\ No newline at end of file
@@ -0,0 +1,10 @@
+ALTER TABLE comment_vote DROP COLUMN updated_at;
+ALTER TABLE comment_vote DROP COLUMN created_at;
+ALTER TABLE post_vote DROP COLUMN updated_at;
+ALTER TABLE post_vote DROP COLUMN created_at;
+ALTER TABLE comment DROP COLUMN updated_at;
+ALTER TABLE comment DROP COLUMN created_at;
+ALTER TABLE post DROP COLUMN updated_at;
+ALTER TABLE post DROP COLUMN created_at;
+ALTER TABLE users DROP COLUMN updated_at;
+ALTER TABLE users DROP COLUMN created_at;
\ No newline at end of file
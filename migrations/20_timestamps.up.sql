@@ -0,0 +1,15 @@
+ALTER TABLE users ADD COLUMN created_at DATETIME;
+ALTER TABLE users ADD COLUMN updated_at DATETIME;
+UPDATE users SET created_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP;
+ALTER TABLE post ADD COLUMN created_at DATETIME;
+ALTER TABLE post ADD COLUMN updated_at DATETIME;
+UPDATE post SET created_at = CURRENT_TIMESTAMP, updated_at = COALESCE(edited_at, CURRENT_TIMESTAMP);
+ALTER TABLE comment ADD COLUMN created_at DATETIME;
+ALTER TABLE comment ADD COLUMN updated_at DATETIME;
+UPDATE comment SET created_at = CURRENT_TIMESTAMP, updated_at = COALESCE(edited_at, CURRENT_TIMESTAMP);
+ALTER TABLE post_vote ADD COLUMN created_at DATETIME;
+ALTER TABLE post_vote ADD COLUMN updated_at DATETIME;
+UPDATE post_vote SET created_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP;
+ALTER TABLE comment_vote ADD COLUMN created_at DATETIME;
+ALTER TABLE comment_vote ADD COLUMN updated_at DATETIME;
+UPDATE comment_vote SET created_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP;
\ No newline at end of file
@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS comment_fts_update;
+DROP TRIGGER IF EXISTS comment_fts_delete;
+DROP TRIGGER IF EXISTS comment_fts_insert;
+DROP TRIGGER IF EXISTS post_fts_update;
+DROP TRIGGER IF EXISTS post_fts_delete;
+DROP TRIGGER IF EXISTS post_fts_insert;
+DROP TABLE IF EXISTS comment_fts;
+DROP TABLE IF EXISTS post_fts;
\ No newline at end of file
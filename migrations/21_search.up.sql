@@ -0,0 +1,24 @@
+CREATE VIRTUAL TABLE IF NOT EXISTS post_fts USING fts5(title, data, content='post', content_rowid='id');
+CREATE VIRTUAL TABLE IF NOT EXISTS comment_fts USING fts5(data, content='comment', content_rowid='id');
+CREATE TRIGGER post_fts_insert AFTER INSERT ON post BEGIN
+    INSERT INTO post_fts(rowid, title, data) VALUES(new.id, new.title, new.data);
+END;
+CREATE TRIGGER post_fts_delete AFTER DELETE ON post BEGIN
+    INSERT INTO post_fts(post_fts, rowid, title, data) VALUES('delete', old.id, old.title, old.data);
+END;
+CREATE TRIGGER post_fts_update AFTER UPDATE OF title, data ON post BEGIN
+    INSERT INTO post_fts(post_fts, rowid, title, data) VALUES('delete', old.id, old.title, old.data);
+    INSERT INTO post_fts(rowid, title, data) VALUES(new.id, new.title, new.data);
+END;
+CREATE TRIGGER comment_fts_insert AFTER INSERT ON comment BEGIN
+    INSERT INTO comment_fts(rowid, data) VALUES(new.id, new.data);
+END;
+CREATE TRIGGER comment_fts_delete AFTER DELETE ON comment BEGIN
+    INSERT INTO comment_fts(comment_fts, rowid, data) VALUES('delete', old.id, old.data);
+END;
+CREATE TRIGGER comment_fts_update AFTER UPDATE OF data ON comment BEGIN
+    INSERT INTO comment_fts(comment_fts, rowid, data) VALUES('delete', old.id, old.data);
+    INSERT INTO comment_fts(rowid, data) VALUES(new.id, new.data);
+END;
+INSERT INTO post_fts(post_fts) VALUES('rebuild');
+INSERT INTO comment_fts(comment_fts) VALUES('rebuild');
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS attachments_post_id;
+DROP TABLE IF EXISTS attachments;
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS post_scheduled;
+ALTER TABLE post DROP COLUMN publish_at;
+ALTER TABLE post DROP COLUMN status;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE post ADD COLUMN status TEXT NOT NULL DEFAULT 'published';
+ALTER TABLE post ADD COLUMN publish_at DATETIME;
+CREATE INDEX IF NOT EXISTS post_scheduled ON post(publish_at) WHERE status = 'scheduled';
\ No newline at end of file
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS poll(
+    id INTEGER PRIMARY KEY AUTOINCREMENT UNIQUE,
+    post_id INTEGER NOT NULL UNIQUE,
+    question TEXT NOT NULL,
+    multiple INTEGER NOT NULL DEFAULT 0,
+    anonymous INTEGER NOT NULL DEFAULT 0,
+    closes_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(post_id) REFERENCES post(id) ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS poll_option(
+    id INTEGER PRIMARY KEY AUTOINCREMENT UNIQUE,
+    poll_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    FOREIGN KEY(poll_id) REFERENCES poll(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS poll_option_poll_id ON poll_option(poll_id);
+CREATE TABLE IF NOT EXISTS poll_vote(
+    poll_id INTEGER NOT NULL,
+    option_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(poll_id, user_id, option_id),
+    FOREIGN KEY(poll_id) REFERENCES poll(id) ON DELETE CASCADE,
+    FOREIGN KEY(option_id) REFERENCES poll_option(id) ON DELETE CASCADE,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS poll_vote_option_id ON poll_vote(option_id);
\ No newline at end of file
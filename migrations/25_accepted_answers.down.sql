@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS post_accepted_comment_id;
+ALTER TABLE users DROP COLUMN reputation;
+ALTER TABLE post DROP COLUMN accepted_comment_id;
\ No newline at end of file
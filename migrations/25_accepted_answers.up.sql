@@ -0,0 +1,3 @@
+ALTER TABLE post ADD COLUMN accepted_comment_id INTEGER REFERENCES comment(id) ON DELETE SET NULL;
+ALTER TABLE users ADD COLUMN reputation INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS post_accepted_comment_id ON post(accepted_comment_id);
\ No newline at end of file
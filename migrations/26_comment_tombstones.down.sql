@@ -0,0 +1 @@
+ALTER TABLE comment DROP COLUMN deleted;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE comment ADD COLUMN deleted INTEGER NOT NULL DEFAULT 0;
\ No newline at end of file
@@ -0,0 +1,35 @@
+// Package blobstore stores uploaded files. LocalStore keeps them on the local file
+// system; other stores, for example one for S3-compatible storage, only have to
+// implement BlobStore.
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"regexp"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// BlobStore stores blobs under keys made of slash-separated names of letters, digits,
+// '_', '-' and '.'.
+type BlobStore interface {
+	// Put stores the content of r under key, replacing an existing blob.
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Open returns the content of the blob, or ErrNotFound.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob. Deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+var keyRe = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9_.-]*(/[A-Za-z0-9_-][A-Za-z0-9_.-]*)*$`)
+
+// IsValidKey reports whether key can be used with a BlobStore. Names can not start
+// with a dot, so keys never point outside of the store.
+func IsValidKey(key string) bool {
+	return len(key) <= 512 && keyRe.MatchString(key)
+}
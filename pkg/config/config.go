@@ -2,13 +2,24 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
+	"strings"
+	"time"
 )
 
 type (
 	Conf struct {
-		API      API      `json:"api"`
-		Database Database `json:"database"`
+		API        API        `json:"api"`
+		Database   Database   `json:"database"`
+		JWT        JWT        `json:"jwt"`
+		Cookie     Cookie     `json:"cookie"`
+		Comments   Comments   `json:"comments"`
+		Uploads    Uploads    `json:"uploads"`
+		Posts      Posts      `json:"posts"`
+		Reputation Reputation `json:"reputation"`
+		Privileges Privileges `json:"privileges"`
 	}
 
 	API struct {
@@ -20,16 +31,171 @@ type (
 		FileName  string `json:"fileName"`
 		SchemeDir string `json:"schemeDir"`
 	}
+	JWT struct {
+		ActiveKey       string       `json:"activeKey"`
+		Keys            []SigningKey `json:"keys"`
+		TokenTTL        Duration     `json:"tokenTTL"`
+		RefreshTokenTTL Duration     `json:"refreshTokenTTL"`
+	}
+	// Cookie enables authentication by HttpOnly cookies with double-submit CSRF tokens.
+	Cookie struct {
+		Enabled bool `json:"enabled"`
+		Secure  bool `json:"secure"`
+	}
+	// Comments.MaxDepth limits how deep replies can be nested; top-level comments have depth 0.
+	Comments struct {
+		MaxDepth int `json:"maxDepth"`
+	}
+	// Uploads limits the attachments of posts. MaxSize is in bytes, MaxDimension and
+	// ThumbnailSize are in pixels, and AllowedTypes are the sniffed MIME types.
+	Uploads struct {
+		Dir           string   `json:"dir"`
+		MaxSize       int64    `json:"maxSize"`
+		MaxFiles      int      `json:"maxFiles"`
+		MaxDimension  int      `json:"maxDimension"`
+		ThumbnailSize int      `json:"thumbnailSize"`
+		AllowedTypes  []string `json:"allowedTypes"`
+	}
+	// Posts.PublishInterval is how often the scheduled posts that are due get published.
+	Posts struct {
+		PublishInterval Duration `json:"publishInterval"`
+	}
+	// Reputation holds the reputation that authors gain for each like and dislike of
+	// their posts and comments and for each of their accepted answers. The dislike
+	// weights are usually negative.
+	Reputation struct {
+		PostLike       int `json:"postLike"`
+		PostDislike    int `json:"postDislike"`
+		CommentLike    int `json:"commentLike"`
+		CommentDislike int `json:"commentDislike"`
+		AcceptedAnswer int `json:"acceptedAnswer"`
+	}
+	// Privileges is the reputation that users need to downvote, to create tags that do
+	// not exist yet, to post links and to change the tags of posts of other users. A
+	// threshold of 0 grants the privilege to everyone.
+	Privileges struct {
+		Downvote    int `json:"downvote"`
+		CreateTag   int `json:"createTag"`
+		PostLinks   int `json:"postLinks"`
+		RetagOthers int `json:"retagOthers"`
+	}
+	SigningKey struct {
+		ID     string `json:"kid"`
+		Secret string `json:"secret"`
+	}
 )
 
+// Duration is a time.Duration that is written as "12h" or "15m" in config.json.
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	duration, err := time.ParseDuration(str)
+	if err != nil {
+		return err
+	}
+	d.Duration = duration
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
 func NewConfig() (*Conf, error) {
 	var newConfig Conf
 	file, err := os.Open("./config/config.json")
 	if err != nil {
 		return nil, err
 	}
+	defer file.Close()
 	if err := json.NewDecoder(file).Decode(&newConfig); err != nil {
 		return nil, err
 	}
+	if err := newConfig.JWT.loadEnv(); err != nil {
+		return nil, err
+	}
+	if err := newConfig.JWT.validate(); err != nil {
+		return nil, err
+	}
+	if newConfig.Comments.MaxDepth <= 0 {
+		return nil, errors.New("comments: maxDepth must be positive")
+	}
+	if err := newConfig.Uploads.validate(); err != nil {
+		return nil, err
+	}
+	if newConfig.Posts.PublishInterval.Duration <= 0 {
+		return nil, errors.New("posts: publishInterval must be positive")
+	}
 	return &newConfig, nil
 }
+
+// loadEnv overrides the jwt settings with FORUM_JWT_KEYS ("kid:secret,kid:secret"),
+// FORUM_JWT_ACTIVE_KEY, FORUM_JWT_TOKEN_TTL and FORUM_JWT_REFRESH_TOKEN_TTL when they are set.
+func (j *JWT) loadEnv() error {
+	if keys, ok := os.LookupEnv("FORUM_JWT_KEYS"); ok {
+		j.Keys = nil
+		for _, pair := range strings.Split(keys, ",") {
+			kid, secret, ok := strings.Cut(strings.TrimSpace(pair), ":")
+			if !ok {
+				return errors.New("FORUM_JWT_KEYS: expected kid:secret pairs")
+			}
+			j.Keys = append(j.Keys, SigningKey{ID: kid, Secret: secret})
+		}
+	}
+	if active, ok := os.LookupEnv("FORUM_JWT_ACTIVE_KEY"); ok {
+		j.ActiveKey = active
+	}
+	if ttl, ok := os.LookupEnv("FORUM_JWT_TOKEN_TTL"); ok {
+		duration, err := time.ParseDuration(ttl)
+		if err != nil {
+			return fmt.Errorf("FORUM_JWT_TOKEN_TTL: %w", err)
+		}
+		j.TokenTTL.Duration = duration
+	}
+	if ttl, ok := os.LookupEnv("FORUM_JWT_REFRESH_TOKEN_TTL"); ok {
+		duration, err := time.ParseDuration(ttl)
+		if err != nil {
+			return fmt.Errorf("FORUM_JWT_REFRESH_TOKEN_TTL: %w", err)
+		}
+		j.RefreshTokenTTL.Duration = duration
+	}
+	return nil
+}
+
+func (j *JWT) validate() error {
+	if j.TokenTTL.Duration <= 0 {
+		return errors.New("jwt: tokenTTL must be positive")
+	}
+	if j.RefreshTokenTTL.Duration <= j.TokenTTL.Duration {
+		return errors.New("jwt: refreshTokenTTL must be longer than tokenTTL")
+	}
+	active := false
+	for _, key := range j.Keys {
+		if key.ID == "" || key.Secret == "" {
+			return errors.New("jwt: key id and secret must not be empty")
+		}
+		if key.ID == j.ActiveKey {
+			active = true
+		}
+	}
+	if !active {
+		return fmt.Errorf("jwt: active key %q is not in keys", j.ActiveKey)
+	}
+	return nil
+}
+
+func (u *Uploads) validate() error {
+	if u.Dir == "" {
+		return errors.New("uploads: dir must not be empty")
+	}
+	if u.MaxSize <= 0 || u.MaxFiles <= 0 || u.MaxDimension <= 0 || u.ThumbnailSize <= 0 {
+		return errors.New("uploads: maxSize, maxFiles, maxDimension and thumbnailSize must be positive")
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+)
+
+// Backup writes a consistent copy of the database to fileName using VACUUM INTO.
+// It refuses to overwrite an existing file.
+func Backup(db *sql.DB, fileName string) error {
+	if fileName == "" {
+		return errors.New("empty backup file name")
+	}
+	if _, err := os.Stat(fileName); err == nil {
+		return errors.New("backup file already exists")
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	_, err := db.Exec("VACUUM INTO $1;", fileName)
+	return err
+}
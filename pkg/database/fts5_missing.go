@@ -0,0 +1,9 @@
+//go:build !sqlite_fts5 && !fts5
+
+package database
+
+import "errors"
+
+func init() {
+	errNoFTS5 = errors.New("sqlite is built without FTS5, which full-text search needs; build with `go build -tags sqlite_fts5`")
+}
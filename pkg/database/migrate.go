@@ -0,0 +1,192 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrDatabaseAhead = errors.New("database schema is newer than this binary")
+	ErrNoDownScheme  = errors.New("migration has no down scheme")
+)
+
+// Migration is a single numbered scheme file pair from schemeDir:
+// NN_name.up.sql and the optional NN_name.down.sql.
+type Migration struct {
+	Version   int
+	Name      string
+	Up        string
+	Down      string
+	AppliedAt time.Time
+}
+
+func (m Migration) IsApplied() bool {
+	return !m.AppliedAt.IsZero()
+}
+
+var schemeName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+const schemaMigrationsTable = `
+CREATE TABLE IF NOT EXISTS schema_migrations(
+    version INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);`
+
+// MigrateUp applies every pending migration in version order, each one in its own
+// transaction, and returns the migrations that were applied.
+func MigrateUp(db *sql.DB, schemeDir string) ([]Migration, error) {
+	migrations, err := MigrationStatus(db, schemeDir)
+	if err != nil {
+		return nil, err
+	}
+	applied := []Migration{}
+	for _, m := range migrations {
+		if m.IsApplied() {
+			continue
+		}
+		if err := runMigration(db, m.Up, func(tx *sql.Tx) error {
+			_, err := tx.Exec("INSERT INTO schema_migrations(version, name) VALUES($1, $2);", m.Version, m.Name)
+			return err
+		}); err != nil {
+			return applied, fmt.Errorf("migration %02d_%s: %w", m.Version, m.Name, err)
+		}
+		applied = append(applied, m)
+	}
+	return applied, nil
+}
+
+// MigrateDown rolls back the last steps applied migrations using their down schemes
+// and returns the migrations that were rolled back.
+func MigrateDown(db *sql.DB, schemeDir string, steps int) ([]Migration, error) {
+	migrations, err := MigrationStatus(db, schemeDir)
+	if err != nil {
+		return nil, err
+	}
+	reverted := []Migration{}
+	for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+		m := migrations[i]
+		if !m.IsApplied() {
+			continue
+		}
+		if m.Down == "" {
+			return reverted, fmt.Errorf("migration %02d_%s: %w", m.Version, m.Name, ErrNoDownScheme)
+		}
+		if err := runMigration(db, m.Down, func(tx *sql.Tx) error {
+			_, err := tx.Exec("DELETE FROM schema_migrations WHERE version = $1;", m.Version)
+			return err
+		}); err != nil {
+			return reverted, fmt.Errorf("migration %02d_%s: %w", m.Version, m.Name, err)
+		}
+		reverted = append(reverted, m)
+	}
+	return reverted, nil
+}
+
+// MigrationStatus returns all known migrations with AppliedAt set for the ones
+// recorded in schema_migrations. It fails with ErrDatabaseAhead when the database
+// has a version that the scheme directory does not know about.
+func MigrationStatus(db *sql.DB, schemeDir string) ([]Migration, error) {
+	if _, err := db.Exec(schemaMigrationsTable); err != nil {
+		return nil, err
+	}
+	migrations, err := getSchemes(schemeDir)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query("SELECT version, applied_at FROM schema_migrations ORDER BY version;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	index := make(map[int]int, len(migrations))
+	for i, m := range migrations {
+		index[m.Version] = i
+	}
+	for rows.Next() {
+		var (
+			version   int
+			appliedAt time.Time
+		)
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		i, ok := index[version]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown version %d", ErrDatabaseAhead, version)
+		}
+		migrations[i].AppliedAt = appliedAt
+	}
+	return migrations, rows.Err()
+}
+
+func runMigration(db *sql.DB, scheme string, record func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(scheme); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := record(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func getSchemes(schemeDir string) ([]Migration, error) {
+	files, err := os.ReadDir(schemeDir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int]*Migration)
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".sql" {
+			continue
+		}
+		parts := schemeName.FindStringSubmatch(file.Name())
+		if parts == nil {
+			return nil, fmt.Errorf("invalid scheme file name: %s", file.Name())
+		}
+		version, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(filepath.Join(schemeDir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: parts[2]}
+			byVersion[version] = m
+		} else if m.Name != parts[2] {
+			return nil, fmt.Errorf("duplicate scheme version %d: %s and %s", version, m.Name, parts[2])
+		}
+		if parts[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %02d_%s has no up scheme", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
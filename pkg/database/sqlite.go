@@ -4,13 +4,18 @@ import (
 	"database/sql"
 	"fmt"
 	"forum/pkg/config"
-	"os"
-	"path/filepath"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// errNoFTS5 is set when the binary is built without the FTS5 module of SQLite, which
+// the search migration needs.
+var errNoFTS5 error
+
 func ConnectSqlte(c *config.Database) (*sql.DB, error) {
+	if errNoFTS5 != nil {
+		return nil, errNoFTS5
+	}
 	db, err := sql.Open(c.Driver, fmt.Sprintf("%v?_foreign_keys=on&cache=shared&mode=rwc", c.FileName))
 	if err != nil {
 		return nil, err
@@ -19,43 +24,5 @@ func ConnectSqlte(c *config.Database) (*sql.DB, error) {
 	if err != nil {
 		return nil, err
 	}
-	if err = makeMigrations(db, c.SchemeDir); err != nil {
-		return nil, err
-	}
-
 	return db, nil
 }
-
-func makeMigrations(db *sql.DB, schemeDir string) error {
-	schemes, err := getSchemes(schemeDir)
-	if err != nil {
-		return err
-	}
-	for _, scheme := range schemes {
-		prep, err := db.Prepare(scheme)
-		if err != nil {
-			return err
-		}
-		if _, err = prep.Exec(); err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
-func getSchemes(schemeDir string) ([]string, error) {
-	var schemes []string
-	files, err := os.ReadDir(schemeDir)
-	if err != nil {
-		return nil, err
-	}
-	for _, file := range files {
-		fileName := filepath.Join(schemeDir, file.Name())
-		data, err := os.ReadFile(fileName)
-		if err != nil {
-			return nil, err
-		}
-		schemes = append(schemes, string(data))
-	}
-	return schemes, nil
-}
@@ -0,0 +1,340 @@
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	autolinkRe = regexp.MustCompile(`^<((?i:https?://|mailto:)[^\s<>]+)>`)
+	bareURLRe  = regexp.MustCompile(`^(?i:https?://)[^\s<]+`)
+	linkTailRe = regexp.MustCompile(`^\(\s*(<[^<>\n]*>|[^\s()]*(?:\([^\s()]*\)[^\s()]*)*)(?:\s+"([^"]*)")?\s*\)`)
+)
+
+// renderInline renders the emphasis, code spans, links and line breaks of a paragraph
+// and escapes everything else.
+func renderInline(text string) string {
+	return renderSpan(text, true)
+}
+
+// renderSpan renders inline Markdown like renderInline. Autolinks and bare URLs are
+// only linked when autolink is set, so that link labels do not get nested links.
+func renderSpan(text string, autolink bool) string {
+	var out strings.Builder
+	sc := &scan{text: text}
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text) && text[i+1] == '\n':
+			out.WriteString("<br>\n")
+			i += 2
+		case c == '\\' && i+1 < len(text) && isPunct(text[i+1]):
+			out.WriteString(html.EscapeString(text[i+1 : i+2]))
+			i += 2
+		case c == '\n':
+			if strings.HasSuffix(text[:i], "  ") {
+				out.WriteString("<br>")
+			}
+			out.WriteString("\n")
+			i++
+		case c == ' ':
+			end := i
+			for end < len(text) && text[end] == ' ' {
+				end++
+			}
+			// trailing spaces are dropped, the newline decides about the break
+			if end == len(text) || text[end] != '\n' {
+				out.WriteString(text[i:end])
+			}
+			i = end
+		case c == '`':
+			i = codeSpan(&out, sc, i)
+		case c == '!' && i+1 < len(text) && text[i+1] == '[':
+			i = link(&out, sc, i+1, true)
+		case c == '[':
+			i = link(&out, sc, i, false)
+		case c == '<':
+			if m := autolinkRe.FindStringSubmatch(text[i:]); m != nil && autolink {
+				writeLink(&out, m[1], "", html.EscapeString(m[1]))
+				i += len(m[0])
+			} else {
+				out.WriteString("&lt;")
+				i++
+			}
+		case autolink && (c == 'h' || c == 'H') && (i == 0 || !isWordByte(text[i-1])) && bareURLRe.MatchString(text[i:]):
+			url := trimURL(bareURLRe.FindString(text[i:]))
+			writeLink(&out, url, "", html.EscapeString(url))
+			i += len(url)
+		case c == '*' || c == '_' || c == '~':
+			i = emphasis(&out, sc, i, autolink)
+		default:
+			_, size := utf8.DecodeRuneInString(text[i:])
+			out.WriteString(html.EscapeString(text[i : i+size]))
+			i += size
+		}
+	}
+	return out.String()
+}
+
+// codeSpan writes the code span that starts at i, or the backticks as text when the
+// span is not closed.
+func codeSpan(out *strings.Builder, sc *scan, i int) int {
+	text := sc.text
+	end, close := sc.codeSpan(i)
+	if close < 0 {
+		out.WriteString(text[i:end])
+		return end
+	}
+	n := end - i
+	code := strings.ReplaceAll(text[end:close], "\n", " ")
+	if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.TrimSpace(code) != "" {
+		code = code[1 : len(code)-1]
+	}
+	out.WriteString("<code>" + html.EscapeString(code) + "</code>")
+	return close + n
+}
+
+// link writes the link or image whose text starts with the bracket at i, or the
+// bracket as text when it is not followed by a destination.
+func link(out *strings.Builder, sc *scan, i int, image bool) int {
+	text := sc.text
+	start := i
+	if image {
+		start--
+	}
+	end := sc.closingBracket(i)
+	if end < 0 {
+		out.WriteString(text[start : i+1])
+		return i + 1
+	}
+	m := linkTailRe.FindStringSubmatch(text[end+1:])
+	if m == nil {
+		out.WriteString(text[start : i+1])
+		return i + 1
+	}
+	url := strings.TrimSuffix(strings.TrimPrefix(m[1], "<"), ">")
+	label := text[i+1 : end]
+	if image {
+		out.WriteString(`<img src="` + html.EscapeString(url) + `" alt="` + html.EscapeString(plainText(label)) + `"`)
+		if m[2] != "" {
+			out.WriteString(` title="` + html.EscapeString(m[2]) + `"`)
+		}
+		out.WriteString(">")
+	} else {
+		writeLink(out, url, m[2], renderSpan(stripLinks(label), false))
+	}
+	return end + 1 + len(m[0])
+}
+
+func writeLink(out *strings.Builder, url, title, content string) {
+	out.WriteString(`<a href="` + html.EscapeString(url) + `"`)
+	if title != "" {
+		out.WriteString(` title="` + html.EscapeString(title) + `"`)
+	}
+	out.WriteString(">" + content + "</a>")
+}
+
+// stripLinks keeps links out of link texts by escaping their brackets.
+func stripLinks(label string) string {
+	return strings.NewReplacer("[", `\[`, "]", `\]`).Replace(label)
+}
+
+// plainText returns the label of an image without Markdown punctuation.
+func plainText(label string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune("*_~`[]\\", r) {
+			return -1
+		}
+		return r
+	}, label)
+}
+
+// trimURL drops the trailing punctuation of a bare URL and closing parentheses that
+// have no opening one inside the URL.
+func trimURL(url string) string {
+	for len(url) > 0 {
+		last := url[len(url)-1]
+		if strings.IndexByte(".,:;!?'\"*_~", last) >= 0 {
+			url = url[:len(url)-1]
+		} else if last == ')' && strings.Count(url, ")") > strings.Count(url, "(") {
+			url = url[:len(url)-1]
+		} else {
+			break
+		}
+	}
+	return url
+}
+
+// emphasis writes the emphasis or strikethrough that starts with the delimiter run at
+// i, or the delimiters as text when the run does not open one.
+func emphasis(out *strings.Builder, sc *scan, i int, autolink bool) int {
+	text := sc.text
+	c := text[i]
+	n := 0
+	for i+n < len(text) && text[i+n] == c {
+		n++
+	}
+	run := text[i : i+n]
+	if c == '~' && n != 2 || n > 3 {
+		out.WriteString(run)
+		return i + n
+	}
+	// the opening run is followed by text and, for underscores, not inside a word
+	if i+n >= len(text) || unicode.IsSpace(rune(text[i+n])) || c == '_' && i > 0 && isWordByte(text[i-1]) {
+		out.WriteString(run)
+		return i + n
+	}
+	end := sc.closingRun(i+n, run)
+	if end < 0 {
+		if n > 1 {
+			// an unclosed ** may still hold a closed *
+			out.WriteString(run[:1])
+			return i + 1
+		}
+		out.WriteString(run)
+		return i + n
+	}
+	inner := renderSpan(text[i+n:end], autolink)
+	switch {
+	case c == '~':
+		out.WriteString("<del>" + inner + "</del>")
+	case n == 1:
+		out.WriteString("<em>" + inner + "</em>")
+	case n == 2:
+		out.WriteString("<strong>" + inner + "</strong>")
+	default:
+		out.WriteString("<em><strong>" + inner + "</strong></em>")
+	}
+	return end + n
+}
+
+// scan finds the closing brackets, delimiter runs and code spans of a text. A scan
+// from a position skips escaped characters and code spans, so every position has one
+// next position and the scans from different positions meet once they reach the same
+// one. The answers for every position are built in one pass from the end of the text,
+// so that a text full of brackets or delimiters is not scanned again for each of them.
+type scan struct {
+	text    string
+	runEnd  []int32 // the end of the run of equal bytes at p
+	code    []int32 // for a backtick at p, the start of the run that closes its code span, or -1
+	next    []int32 // the position that a scan looks at after p
+	bracket []int32 // the first unmatched closing bracket at or after p, or -1
+	closers map[string][]int32
+}
+
+// build fills the tables of the scan the first time they are needed.
+func (sc *scan) build() {
+	if sc.next != nil {
+		return
+	}
+	text, n := sc.text, len(sc.text)
+	sc.runEnd = make([]int32, n+1)
+	sc.code = make([]int32, n+1)
+	sc.next = make([]int32, n+1)
+	sc.bracket = make([]int32, n+1)
+	sc.bracket[n] = -1
+	// the nearest run of backticks after p for each length, only a run of the same
+	// length closes a code span
+	codeClose := make(map[int32]int32)
+	for p := n - 1; p >= 0; p-- {
+		sc.runEnd[p] = int32(p + 1)
+		if p+1 < n && text[p+1] == text[p] {
+			sc.runEnd[p] = sc.runEnd[p+1]
+		}
+		switch text[p] {
+		case '\\':
+			sc.next[p] = int32(min(p+2, n))
+		case '`':
+			length := sc.runEnd[p] - int32(p)
+			sc.code[p] = -1
+			sc.next[p] = sc.runEnd[p]
+			if close, ok := codeClose[length]; ok {
+				sc.code[p] = close
+				sc.next[p] = close + length
+			}
+			if p == 0 || text[p-1] != '`' {
+				codeClose[length] = int32(p)
+			}
+		default:
+			sc.next[p] = int32(p + 1)
+		}
+		switch text[p] {
+		case ']':
+			sc.bracket[p] = int32(p)
+		case '[':
+			sc.bracket[p] = -1
+			if close := sc.bracket[sc.next[p]]; close >= 0 {
+				sc.bracket[p] = sc.bracket[close+1]
+			}
+		default:
+			sc.bracket[p] = sc.bracket[sc.next[p]]
+		}
+	}
+}
+
+// codeSpan returns the end of the backticks at i and the start of the run that closes
+// the code span they open, or -1 when it is not closed.
+func (sc *scan) codeSpan(i int) (int, int) {
+	sc.build()
+	return int(sc.runEnd[i]), int(sc.code[i])
+}
+
+// closingBracket returns the index of the bracket that closes the one at i, or -1.
+// Brackets inside code spans do not count.
+func (sc *scan) closingBracket(i int) int {
+	sc.build()
+	return int(sc.bracket[i+1])
+}
+
+// closingRun returns the index of the delimiter run that closes an emphasis opened by
+// run, skipping code spans, or -1.
+func (sc *scan) closingRun(from int, run string) int {
+	sc.build()
+	closers, ok := sc.closers[run]
+	if !ok {
+		closers = sc.buildClosers(run)
+	}
+	return int(closers[from])
+}
+
+// buildClosers returns, for every position, the first delimiter run at or after it
+// that closes an emphasis opened by run, or -1. Runs of the delimiter that do not
+// close it are skipped as a whole.
+func (sc *scan) buildClosers(run string) []int32 {
+	text, n := sc.text, len(sc.text)
+	c := run[0]
+	closers := make([]int32, n+1)
+	closers[n] = -1
+	for p := n - 1; p >= 0; p-- {
+		if text[p] != c {
+			closers[p] = closers[sc.next[p]]
+			continue
+		}
+		end := int(sc.runEnd[p])
+		closes := end-p == len(run) && p > 0 && !unicode.IsSpace(rune(text[p-1]))
+		if c == '_' && end < n && isWordByte(text[end]) {
+			closes = false
+		}
+		if closes {
+			closers[p] = int32(p)
+		} else {
+			closers[p] = closers[end]
+		}
+	}
+	if sc.closers == nil {
+		sc.closers = make(map[string][]int32)
+	}
+	sc.closers[run] = closers
+	return closers
+}
+
+func isPunct(c byte) bool {
+	return c < utf8.RuneSelf && unicode.IsPunct(rune(c)) || strings.IndexByte("$+<=>^`|~", c) >= 0
+}
+
+func isWordByte(c byte) bool {
+	return c >= utf8.RuneSelf || c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
@@ -0,0 +1,369 @@
+// Package markdown renders the Markdown of posts and comments to safe HTML.
+//
+// It supports paragraphs, ATX headings, block quotes, nested lists, fenced and
+// indented code blocks, thematic breaks, GFM tables, emphasis, strikethrough, code
+// spans, links, images, autolinks and hard line breaks. Raw HTML in the source is
+// escaped, and the rendered HTML goes through Sanitize before it is returned.
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxDepth limits the nesting of block quotes and lists.
+const maxDepth = 16
+
+var (
+	headingRe   = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	ruleRe      = regexp.MustCompile(`^ {0,3}(?:(?:-[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	fenceRe     = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})[ \t]*([^`]*)$")
+	quoteRe     = regexp.MustCompile(`^ {0,3}> ?`)
+	bulletRe    = regexp.MustCompile(`^( {0,3})([-*+])([ \t]+|$)`)
+	orderedRe   = regexp.MustCompile(`^( {0,3})(\d{1,9})([.)])([ \t]+|$)`)
+	delimiterRe = regexp.MustCompile(`^ {0,3}\|?[ \t]*:?-+:?[ \t]*(?:\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+	languageRe  = regexp.MustCompile(`^[A-Za-z0-9_+#.-]+$`)
+	linkRe      = regexp.MustCompile(`<(?:a href|img src)="([^"]*)"`)
+)
+
+// Render returns the sanitized HTML of the Markdown source.
+func Render(source string) string {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+	source = strings.ReplaceAll(source, "\r", "\n")
+	source = strings.ReplaceAll(source, "\x00", "\uFFFD")
+	return Sanitize(renderBlocks(strings.Split(source, "\n"), 0))
+}
+
+// HasLinks reports whether the source renders to any links, including autolinks and
+// bare URLs, or images. Images count because they load external URLs too. Links that
+// Sanitize drops, like javascript: ones, do not count.
+func HasLinks(source string) bool {
+	return len(Links(source)) > 0
+}
+
+// Links returns the URLs of the links and images that the source renders to, in the
+// order they appear.
+func Links(source string) []string {
+	matches := linkRe.FindAllStringSubmatch(Render(source), -1)
+	links := make([]string, 0, len(matches))
+	for _, match := range matches {
+		links = append(links, html.UnescapeString(match[1]))
+	}
+	return links
+}
+
+func renderBlocks(lines []string, depth int) string {
+	var out strings.Builder
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case isBlank(line):
+			i++
+		case fenceRe.MatchString(line):
+			i = renderFence(&out, lines, i)
+		case indent(line) >= 4:
+			i = renderIndentedCode(&out, lines, i)
+		case headingRe.MatchString(line):
+			m := headingRe.FindStringSubmatch(line)
+			level := strconv.Itoa(len(m[1]))
+			out.WriteString("<h" + level + ">" + renderInline(m[2]) + "</h" + level + ">\n")
+			i++
+		case ruleRe.MatchString(line):
+			out.WriteString("<hr>\n")
+			i++
+		case quoteRe.MatchString(line) && depth < maxDepth:
+			i = renderQuote(&out, lines, i, depth)
+		case isListItem(line) && depth < maxDepth:
+			i = renderList(&out, lines, i, depth)
+		case isTableStart(lines, i):
+			i = renderTable(&out, lines, i)
+		default:
+			i = renderParagraph(&out, lines, i)
+		}
+	}
+	return out.String()
+}
+
+func renderFence(out *strings.Builder, lines []string, i int) int {
+	m := fenceRe.FindStringSubmatch(lines[i])
+	fenceIndent, fence := len(m[1]), m[2]
+	info := strings.Fields(m[3])
+	var code []string
+	i++
+	for ; i < len(lines); i++ {
+		trimmed := strings.TrimLeft(lines[i], " ")
+		if indent(lines[i]) < 4 && strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]+" \t") == "" {
+			i++
+			break
+		}
+		code = append(code, dedent(lines[i], fenceIndent))
+	}
+	out.WriteString("<pre><code")
+	if len(info) > 0 && languageRe.MatchString(info[0]) {
+		out.WriteString(` class="language-` + html.EscapeString(info[0]) + `"`)
+	}
+	out.WriteString(">")
+	for _, line := range code {
+		out.WriteString(html.EscapeString(line) + "\n")
+	}
+	out.WriteString("</code></pre>\n")
+	return i
+}
+
+func renderIndentedCode(out *strings.Builder, lines []string, i int) int {
+	var code []string
+	for ; i < len(lines) && (indent(lines[i]) >= 4 || isBlank(lines[i])); i++ {
+		code = append(code, dedent(lines[i], 4))
+	}
+	for len(code) > 0 && isBlank(code[len(code)-1]) {
+		code = code[:len(code)-1]
+	}
+	out.WriteString("<pre><code>")
+	for _, line := range code {
+		out.WriteString(html.EscapeString(line) + "\n")
+	}
+	out.WriteString("</code></pre>\n")
+	return i
+}
+
+func renderQuote(out *strings.Builder, lines []string, i int, depth int) int {
+	var quoted []string
+	for ; i < len(lines) && !isBlank(lines[i]); i++ {
+		if loc := quoteRe.FindStringIndex(lines[i]); loc != nil {
+			quoted = append(quoted, lines[i][loc[1]:])
+		} else if len(quoted) > 0 && !startsBlock(lines[i]) {
+			// lazy continuation of a quoted paragraph
+			quoted = append(quoted, lines[i])
+		} else {
+			break
+		}
+	}
+	out.WriteString("<blockquote>\n" + renderBlocks(quoted, depth+1) + "</blockquote>\n")
+	return i
+}
+
+func isListItem(line string) bool {
+	return !ruleRe.MatchString(line) && (bulletRe.MatchString(line) || orderedRe.MatchString(line))
+}
+
+// listMarker returns the list type, the start number and the width of the marker of a
+// list item line.
+func listMarker(line string) (ordered bool, start int, width int) {
+	if m := orderedRe.FindStringSubmatch(line); m != nil {
+		start, _ = strconv.Atoi(m[2])
+		return true, start, markerWidth(m)
+	}
+	return false, 0, markerWidth(bulletRe.FindStringSubmatch(line))
+}
+
+func markerWidth(m []string) int {
+	width := 0
+	for _, part := range m[1:] {
+		width += len(part)
+	}
+	if spaces := len(m[len(m)-1]); spaces == 0 || spaces > 4 {
+		// the content starts one space after the marker
+		width += 1 - spaces
+	}
+	return width
+}
+
+func renderList(out *strings.Builder, lines []string, i int, depth int) int {
+	ordered, start, _ := listMarker(lines[i])
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+		if start != 1 {
+			out.WriteString(`<ol start="` + strconv.Itoa(start) + `">` + "\n")
+		} else {
+			out.WriteString("<ol>\n")
+		}
+	} else {
+		out.WriteString("<ul>\n")
+	}
+	for i < len(lines) && isListItem(lines[i]) {
+		itemOrdered, _, width := listMarker(lines[i])
+		if itemOrdered != ordered {
+			break
+		}
+		item := []string{strings.TrimLeft(lines[i][min(width, len(lines[i])):], " \t")}
+		i++
+		for i < len(lines) {
+			line := lines[i]
+			if isBlank(line) {
+				// a blank line continues the item only when indented content follows
+				next := i + 1
+				for next < len(lines) && isBlank(lines[next]) {
+					next++
+				}
+				if next < len(lines) && indent(lines[next]) >= width {
+					for ; i < next; i++ {
+						item = append(item, "")
+					}
+					continue
+				}
+				break
+			}
+			if indent(line) >= width {
+				item = append(item, dedent(line, width))
+			} else if !isListItem(line) && !startsBlock(line) && !isBlank(item[len(item)-1]) {
+				// lazy continuation of the item paragraph
+				item = append(item, line)
+			} else {
+				break
+			}
+			i++
+		}
+		body := renderBlocks(item, depth+1)
+		// tight items hold their text without a paragraph
+		if strings.HasPrefix(body, "<p>") && strings.Count(body, "<p>") == 1 {
+			body = strings.Replace(body, "<p>", "", 1)
+			body = strings.Replace(body, "</p>\n", "", 1)
+		}
+		out.WriteString("<li>" + strings.TrimSuffix(body, "\n") + "</li>\n")
+		// a blank line between items does not end the list
+		if i+1 < len(lines) && isBlank(lines[i]) && isListItem(lines[i+1]) {
+			i++
+		}
+	}
+	out.WriteString("</" + tag + ">\n")
+	return i
+}
+
+func isTableStart(lines []string, i int) bool {
+	if i+1 >= len(lines) || !strings.Contains(lines[i], "|") || !delimiterRe.MatchString(lines[i+1]) {
+		return false
+	}
+	return len(splitRow(lines[i])) == len(splitRow(lines[i+1]))
+}
+
+func renderTable(out *strings.Builder, lines []string, i int) int {
+	header := splitRow(lines[i])
+	aligns := make([]string, len(header))
+	for j, cell := range splitRow(lines[i+1]) {
+		cell = strings.TrimSpace(cell)
+		switch {
+		case strings.HasPrefix(cell, ":") && strings.HasSuffix(cell, ":"):
+			aligns[j] = "center"
+		case strings.HasSuffix(cell, ":"):
+			aligns[j] = "right"
+		case strings.HasPrefix(cell, ":"):
+			aligns[j] = "left"
+		}
+	}
+	out.WriteString("<table>\n<thead>\n")
+	writeRow(out, "th", header, aligns)
+	out.WriteString("</thead>\n")
+	i += 2
+	if i < len(lines) && strings.Contains(lines[i], "|") && !isBlank(lines[i]) {
+		out.WriteString("<tbody>\n")
+		for ; i < len(lines) && strings.Contains(lines[i], "|") && !isBlank(lines[i]); i++ {
+			writeRow(out, "td", splitRow(lines[i]), aligns)
+		}
+		out.WriteString("</tbody>\n")
+	}
+	out.WriteString("</table>\n")
+	return i
+}
+
+// writeRow writes a table row with exactly one cell per column.
+func writeRow(out *strings.Builder, tag string, cells []string, aligns []string) {
+	out.WriteString("<tr>")
+	for j, align := range aligns {
+		out.WriteString("<" + tag)
+		if align != "" {
+			out.WriteString(` align="` + align + `"`)
+		}
+		out.WriteString(">")
+		if j < len(cells) {
+			out.WriteString(renderInline(strings.TrimSpace(cells[j])))
+		}
+		out.WriteString("</" + tag + ">")
+	}
+	out.WriteString("</tr>\n")
+}
+
+// splitRow splits a table row on the pipes that are not escaped, without the leading
+// and trailing pipe.
+func splitRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+	var cells []string
+	var cell strings.Builder
+	for k := 0; k < len(line); k++ {
+		switch {
+		case line[k] == '\\' && k+1 < len(line) && line[k+1] == '|':
+			cell.WriteByte('|')
+			k++
+		case line[k] == '|':
+			cells = append(cells, cell.String())
+			cell.Reset()
+		default:
+			cell.WriteByte(line[k])
+		}
+	}
+	return append(cells, cell.String())
+}
+
+func renderParagraph(out *strings.Builder, lines []string, i int) int {
+	var text []string
+	for ; i < len(lines) && !isBlank(lines[i]); i++ {
+		if len(text) > 0 && (startsBlock(lines[i]) || isTableStart(lines, i)) {
+			break
+		}
+		text = append(text, lines[i])
+	}
+	out.WriteString("<p>" + renderInline(strings.Join(text, "\n")) + "</p>\n")
+	return i
+}
+
+// startsBlock reports whether the line starts a block that interrupts a paragraph.
+func startsBlock(line string) bool {
+	return fenceRe.MatchString(line) || headingRe.MatchString(line) || ruleRe.MatchString(line) ||
+		quoteRe.MatchString(line) || bulletRe.MatchString(line) && !isBlank(line[len(bulletRe.FindString(line)):])
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// indent returns the width of the leading whitespace of the line, with tabs to the
+// next multiple of 4.
+func indent(line string) int {
+	width := 0
+	for _, c := range line {
+		switch c {
+		case ' ':
+			width++
+		case '\t':
+			width += 4 - width%4
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+// dedent removes up to width columns of leading whitespace.
+func dedent(line string, width int) string {
+	removed := 0
+	for k, c := range line {
+		if removed >= width {
+			return line[k:]
+		}
+		switch c {
+		case ' ':
+			removed++
+		case '\t':
+			removed += 4 - removed%4
+		default:
+			return line[k:]
+		}
+	}
+	return ""
+}
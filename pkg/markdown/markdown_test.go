@@ -0,0 +1,180 @@
+package markdown
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		// javascript: and other unsafe URLs lose their href or src
+		{"javascript url", "[a](javascript:alert(1))", "<p><a rel=\"nofollow\">a</a></p>\n"},
+		{"mixed case scheme", "[a](JaVaScRiPt:alert(1))", "<p><a rel=\"nofollow\">a</a></p>\n"},
+		{"leading whitespace", "[a]( javascript:alert(1))", "<p><a rel=\"nofollow\">a</a></p>\n"},
+		{"angle brackets", "[a](<javascript:alert(1)>)", "<p><a rel=\"nofollow\">a</a></p>\n"},
+		{"decimal entity", "[a](&#106;avascript:alert(1))", "<p><a rel=\"nofollow\">a</a></p>\n"},
+		{"hex entity", "[a](&#x6A;avascript:alert(1))", "<p><a rel=\"nofollow\">a</a></p>\n"},
+		{"entity tab", "[a](java&#x09;script:alert(1))", "<p><a rel=\"nofollow\">a</a></p>\n"},
+		{"vbscript url", "[a](vbscript:x)", "<p><a rel=\"nofollow\">a</a></p>\n"},
+		{"data url", "[a](data:text/html,x)", "<p><a rel=\"nofollow\">a</a></p>\n"},
+		{"javascript autolink", "<javascript:alert(1)>", "<p>&lt;javascript:alert(1)&gt;</p>\n"},
+		{"javascript image", "![i](javascript:alert(1))", "<p><img alt=\"i\"></p>\n"},
+		{"http link", "[a](http://x/)", "<p><a href=\"http://x/\" rel=\"nofollow\">a</a></p>\n"},
+		{"relative link", "[a](/post/1)", "<p><a href=\"/post/1\" rel=\"nofollow\">a</a></p>\n"},
+		{"autolink", "<https://x/>", "<p><a href=\"https://x/\" rel=\"nofollow\">https://x/</a></p>\n"},
+		{"bare url", "see https://x/.", "<p>see <a href=\"https://x/\" rel=\"nofollow\">https://x/</a>.</p>\n"},
+
+		// titles and alt texts stay inside their attribute
+		{"title", `[a](http://x/ "t")`, "<p><a href=\"http://x/\" title=\"t\" rel=\"nofollow\">a</a></p>\n"},
+		{"title with entities", `[a](http://x/ "a&quot; onclick=&quot;alert(1)")`, "<p><a href=\"http://x/\" title=\"a&amp;quot; onclick=&amp;quot;alert(1)\" rel=\"nofollow\">a</a></p>\n"},
+		{"title with escaped quotes", `[a](http://x/ "t\" onclick=\"alert(1)")`, "<p>[a](<a href=\"http://x/\" rel=\"nofollow\">http://x/</a> &#34;t&#34; onclick=&#34;alert(1)&#34;)</p>\n"},
+		{"quote in url", `[a](http://x/" onclick="alert(1))`, "<p>[a](<a href=\"http://x/\" rel=\"nofollow\">http://x/</a>&#34; onclick=&#34;alert(1))</p>\n"},
+		{"image title", `![i](http://x/a.png "\"><script>")`, "<p>![i](<a href=\"http://x/a.png\" rel=\"nofollow\">http://x/a.png</a> &#34;&#34;&gt;&lt;script&gt;&#34;)</p>\n"},
+		{"image alt", `![a" onerror="alert(1)](http://x/a.png)`, "<p><img src=\"http://x/a.png\" alt=\"a&#34; onerror=&#34;alert(1)\"></p>\n"},
+
+		// raw HTML is escaped
+		{"script", "<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>\n"},
+		{"event handler", "<img src=x onerror=alert(1)>", "<p>&lt;img src=x onerror=alert(1)&gt;</p>\n"},
+		{"raw link", `<a href="javascript:alert(1)">a</a>`, "<p>&lt;a href=&#34;javascript:alert(1)&#34;&gt;a&lt;/a&gt;</p>\n"},
+		{"allowed element", "<b>b</b>", "<p>&lt;b&gt;b&lt;/b&gt;</p>\n"},
+		{"comment", "a <!-- c --> b", "<p>a &lt;!-- c --&gt; b</p>\n"},
+
+		// code is escaped and not parsed
+		{"code span", "`<b>`", "<p><code>&lt;b&gt;</code></p>\n"},
+		{"code span with backtick", "``a`b``", "<p><code>a`b</code></p>\n"},
+		{"link in code span", "`[a](javascript:x)`", "<p><code>[a](javascript:x)</code></p>\n"},
+		{"fence", "```go\n<script>\n```", "<pre><code class=\"language-go\">&lt;script&gt;\n</code></pre>\n"},
+		{"fence info injection", "```\"><x\n```", "<pre><code></code></pre>\n"},
+		{"indented code", "    <i>i</i>", "<pre><code>&lt;i&gt;i&lt;/i&gt;\n</code></pre>\n"},
+		{"unclosed fence", "~~~\nunclosed", "<pre><code>unclosed\n</code></pre>\n"},
+
+		// tables
+		{"table", "| a | b |\n|:--|--:|\n| <x> | a \\| b |", "<table>\n<thead>\n<tr><th align=\"left\">a</th><th align=\"right\">b</th></tr>\n</thead>\n<tbody>\n<tr><td align=\"left\">&lt;x&gt;</td><td align=\"right\">a | b</td></tr>\n</tbody>\n</table>\n"},
+		{"table without delimiter", "| a |\n| b |", "<p>| a |\n| b |</p>\n"},
+
+		// link labels do not get nested links
+		{"image in link", "[![i](http://a/b.png)](http://c/)", "<p><a href=\"http://c/\" rel=\"nofollow\">![i](http://a/b.png)</a></p>\n"},
+		{"bare url in label", "[see http://a/](http://c/)", "<p><a href=\"http://c/\" rel=\"nofollow\">see http://a/</a></p>\n"},
+		{"autolink in label", "[<http://a/>](http://c/)", "<p><a href=\"http://c/\" rel=\"nofollow\">&lt;http://a/&gt;</a></p>\n"},
+		{"emphasis in label", "[*see http://a/*](http://c/)", "<p><a href=\"http://c/\" rel=\"nofollow\"><em>see http://a/</em></a></p>\n"},
+		{"bare url in emphasis", "*see http://a/*", "<p><em>see <a href=\"http://a/\" rel=\"nofollow\">http://a/</a></em></p>\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Render(tt.source); got != tt.want {
+				t.Errorf("Render(%q)\n got %q\nwant %q", tt.source, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderDepth(t *testing.T) {
+	var list strings.Builder
+	for i := 0; i < 40; i++ {
+		list.WriteString(strings.Repeat("  ", i) + "- a\n")
+	}
+	tests := []struct {
+		name   string
+		source string
+		tag    string
+	}{
+		{"block quotes", strings.Repeat("> ", 40) + "a", "blockquote"},
+		{"lists", list.String(), "ul"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Render(tt.source)
+			open, closed := strings.Count(got, "<"+tt.tag+">"), strings.Count(got, "</"+tt.tag+">")
+			if open != maxDepth || closed != maxDepth {
+				t.Errorf("got %d <%s> and %d </%s>, want %d of each", open, tt.tag, closed, tt.tag, maxDepth)
+			}
+		})
+	}
+}
+
+// TestRenderLarge renders inputs that would make Render scan the rest of the text for
+// every bracket, delimiter or backtick.
+func TestRenderLarge(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"brackets and backticks", strings.Repeat("[`", 20000)},
+		{"unclosed strikethrough", strings.Repeat("~~a ", 10000)},
+		{"unclosed emphasis and backticks", strings.Repeat("*a `", 10000)},
+		{"nested brackets", strings.Repeat("[", 20000) + strings.Repeat("]", 20000)},
+		{"code spans", strings.Repeat("`` `", 10000)},
+		{"spaces", "a" + strings.Repeat(" ", 40000) + "b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			Render(tt.source)
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("Render took %v, want less than %v", elapsed, time.Second)
+			}
+		})
+	}
+}
+
+func TestHasLinks(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   bool
+	}{
+		{"text", "no links here", false},
+		{"link", "[a](http://x/)", true},
+		{"bare url", "see https://x/", true},
+		{"autolink", "<https://x/>", true},
+		{"image", "![i](http://x/a.png)", true},
+		{"javascript link", "[a](javascript:alert(1))", false},
+		{"url in code", "`http://x/`", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasLinks(tt.source); got != tt.want {
+				t.Errorf("HasLinks(%q) = %v, want %v", tt.source, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinks(t *testing.T) {
+	got := Links("[a](http://x/?a=1&b=2) ![i](http://y/i.png) <https://z/> and `http://code/`")
+	want := []string{"http://x/?a=1&b=2", "http://y/i.png", "https://z/"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Links() = %q, want %q", got, want)
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{"upper case scheme", `<a href="JAVASCRIPT:alert(1)">a</a>`, "<a rel=\"nofollow\">a</a>"},
+		{"leading whitespace", `<a href=" javascript:alert(1)">a</a>`, "<a rel=\"nofollow\">a</a>"},
+		{"entity tab", `<a href="jav&#x09;ascript:alert(1)">a</a>`, "<a rel=\"nofollow\">a</a>"},
+		{"entity letter", `<a href="&#106;avascript:alert(1)">a</a>`, "<a rel=\"nofollow\">a</a>"},
+		{"event handler", `<a href="http://x/" onclick="alert(1)">a</a>`, "<a href=\"http://x/\" rel=\"nofollow\">a</a>"},
+		{"image event handler", `<img src="http://x/a.png" onerror="alert(1)">`, "<img src=\"http://x/a.png\">"},
+		{"invalid class", `<code class="x onload">a</code>`, "<code>a</code>"},
+		{"unknown element", `<div>a</div>`, "&lt;div&gt;a&lt;/div&gt;"},
+		{"entities", `<p>a &amp; b</p>`, "<p>a &amp; b</p>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sanitize(tt.html); got != tt.want {
+				t.Errorf("Sanitize(%q)\n got %q\nwant %q", tt.html, got, tt.want)
+			}
+		})
+	}
+}
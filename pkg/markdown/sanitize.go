@@ -0,0 +1,112 @@
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// allowed lists the elements that Sanitize keeps and their attributes.
+var allowed = map[string][]string{
+	"p": nil, "br": nil, "hr": nil, "blockquote": nil,
+	"h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+	"ul": nil, "ol": {"start"}, "li": nil,
+	"pre": nil, "code": {"class"},
+	"em": nil, "strong": nil, "del": nil,
+	"a":     {"href", "title"},
+	"img":   {"src", "alt", "title"},
+	"table": nil, "thead": nil, "tbody": nil, "tr": nil,
+	"th": {"align"}, "td": {"align"},
+}
+
+var (
+	tagRe       = regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9]*)((?:\s+[a-zA-Z-]+="[^"]*")*)\s*/?>`)
+	attrRe      = regexp.MustCompile(`([a-zA-Z-]+)="([^"]*)"`)
+	attrValueRe = map[string]*regexp.Regexp{
+		"class": regexp.MustCompile(`^language-[A-Za-z0-9_+#.-]+$`),
+		"start": regexp.MustCompile(`^\d{1,9}$`),
+		"align": regexp.MustCompile(`^(left|center|right)$`),
+	}
+)
+
+// Sanitize keeps the allowed elements and attributes of the HTML and escapes every
+// other tag. Links and images must point to http, https or mailto URLs or to relative
+// ones, and links get rel="nofollow".
+func Sanitize(s string) string {
+	var out strings.Builder
+	last := 0
+	for _, loc := range tagRe.FindAllStringSubmatchIndex(s, -1) {
+		out.WriteString(escapeText(s[last:loc[0]]))
+		last = loc[1]
+		closing := loc[3] > loc[2]
+		name := strings.ToLower(s[loc[4]:loc[5]])
+		attrs, ok := allowed[name]
+		if !ok {
+			out.WriteString(html.EscapeString(s[loc[0]:loc[1]]))
+			continue
+		}
+		if closing {
+			out.WriteString("</" + name + ">")
+			continue
+		}
+		out.WriteString("<" + name)
+		for _, m := range attrRe.FindAllStringSubmatch(s[loc[6]:loc[7]], -1) {
+			attr, value := strings.ToLower(m[1]), html.UnescapeString(m[2])
+			if !allowedAttr(attrs, attr, value) {
+				continue
+			}
+			out.WriteString(" " + attr + `="` + html.EscapeString(value) + `"`)
+		}
+		if name == "a" {
+			out.WriteString(` rel="nofollow"`)
+		}
+		out.WriteString(">")
+	}
+	out.WriteString(escapeText(s[last:]))
+	return out.String()
+}
+
+func allowedAttr(attrs []string, attr, value string) bool {
+	found := false
+	for _, a := range attrs {
+		found = found || a == attr
+	}
+	if !found {
+		return false
+	}
+	if attr == "href" || attr == "src" {
+		return safeURL(value)
+	}
+	if re, ok := attrValueRe[attr]; ok {
+		return re.MatchString(value)
+	}
+	return true
+}
+
+// safeURL reports whether the URL uses an allowed scheme or is relative.
+func safeURL(raw string) bool {
+	raw = strings.TrimSpace(raw)
+	if strings.ContainsAny(raw, "\x00\t\n\r") {
+		return false
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "mailto":
+		return true
+	case "":
+		// a colon before the first slash would be read as a scheme by browsers
+		path, _, _ := strings.Cut(raw, "/")
+		return !strings.Contains(path, ":")
+	}
+	return false
+}
+
+// escapeText escapes the text between tags, keeping the entities that are already
+// escaped.
+func escapeText(s string) string {
+	return html.EscapeString(html.UnescapeString(s))
+}
@@ -76,6 +76,15 @@ func (j *jwt) SetPayload(key, data string) {
 	j.payload[key] = data
 }
 
+func (j *jwt) SetHeader(key, data string) {
+	j.header[key] = data
+}
+
+func (j *jwt) GetHeader(key string) (interface{}, bool) {
+	data, ok := j.header[key]
+	return data, ok
+}
+
 func (j *jwt) GetPayload(key string) (interface{}, bool) {
 	data, ok := j.payload[key]
 	return data, ok
@@ -86,7 +95,7 @@ func Parse(token string) (*jwt, error) {
 	if len(splitted) != 3 {
 		return nil, errors.New("invalid token")
 	}
-	_, err := DecodeBase64(splitted[0])
+	header, err := DecodeBase64(splitted[0])
 	if err != nil {
 		return nil, errors.New("invalid token")
 	}
@@ -95,6 +104,10 @@ func Parse(token string) (*jwt, error) {
 		return nil, errors.New("invalid token")
 	}
 	jwt := New()
+	err = json.Unmarshal(header, &jwt.header)
+	if err != nil {
+		return nil, errors.New("invalid token")
+	}
 	err = json.Unmarshal(payload, &jwt.payload)
 	if err != nil {
 		return nil, errors.New("invalid token")
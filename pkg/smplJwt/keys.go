@@ -0,0 +1,42 @@
+package smpljwt
+
+import "errors"
+
+var (
+	ErrUnknownKey = errors.New("unknown signing key")
+	ErrEmptyKeyID = errors.New("empty key id")
+)
+
+// KeySet holds every key that may verify a token and the id of the key that signs
+// new tokens. Retiring a key is done by removing it from the set.
+type KeySet struct {
+	active string
+	keys   map[string]string
+}
+
+func NewKeySet(active string, keys map[string]string) (*KeySet, error) {
+	for kid, secret := range keys {
+		if kid == "" {
+			return nil, ErrEmptyKeyID
+		}
+		if secret == "" {
+			return nil, ErrSecret
+		}
+	}
+	if _, ok := keys[active]; !ok {
+		return nil, ErrUnknownKey
+	}
+	return &KeySet{active: active, keys: keys}, nil
+}
+
+func (k *KeySet) signingKey() (string, string) {
+	return k.active, k.keys[k.active]
+}
+
+func (k *KeySet) verificationKey(kid string) (string, error) {
+	secret, ok := k.keys[kid]
+	if !ok {
+		return "", ErrUnknownKey
+	}
+	return secret, nil
+}
@@ -15,14 +15,19 @@ var (
 	ErrInvalidID    = errors.New("invalid id")
 )
 
-func ParseToken(token string, secret string) (int, error) {
-	if secret == "" {
-		return -1, ErrSecret
-	}
+func ParseToken(token string, keys *KeySet) (int, error) {
 	jwt, err := Parse(token)
 	if err != nil {
 		return -1, err
 	}
+	kid, ok := jwt.GetHeader("kid")
+	if !ok {
+		return -1, ErrUnknownKey
+	}
+	secret, err := keys.verificationKey(fmt.Sprintf("%v", kid))
+	if err != nil {
+		return -1, err
+	}
 	if err := jwt.Verify(token, secret); err != nil {
 		return -1, err
 	}
@@ -49,13 +54,15 @@ func ParseToken(token string, secret string) (int, error) {
 	return id, nil
 }
 
-func NewJWT(id uint, secret string) (string, error) {
+func NewJWT(id uint, keys *KeySet, ttl time.Duration) (string, error) {
+	kid, secret := keys.signingKey()
 	if secret == "" {
 		return "", ErrSecret
 	}
 	jwt := New()
+	jwt.SetHeader("kid", kid)
 	jwt.SetPayload("id", fmt.Sprintf("%v", id))
-	jwt.SetPayload("exp", fmt.Sprintf("%v", time.Now().Add(12*time.Hour).Unix()))
+	jwt.SetPayload("exp", fmt.Sprintf("%v", time.Now().Add(ttl).Unix()))
 	token, err := jwt.Sign(secret)
 	return token, err
 }
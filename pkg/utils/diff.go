@@ -0,0 +1,76 @@
+package utils
+
+import "strings"
+
+// maxDiffCells limits the size of the table that LineDiff builds for the changed
+// lines. Larger changes are shown as the removal of every old line followed by the
+// addition of every new one.
+const maxDiffCells = 1 << 20
+
+// LineDiff returns a line diff from oldText to newText. Unchanged lines start with
+// "  ", removed lines with "- " and added lines with "+ ".
+func LineDiff(oldText, newText string) string {
+	a := strings.Split(oldText, "\n")
+	b := strings.Split(newText, "\n")
+	// the lines before and after the change are kept as they are
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	var diff strings.Builder
+	for _, line := range a[:prefix] {
+		diff.WriteString("  " + line + "\n")
+	}
+	changedA, changedB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if (len(changedA)+1)*(len(changedB)+1) > maxDiffCells {
+		for _, line := range changedA {
+			diff.WriteString("- " + line + "\n")
+		}
+		for _, line := range changedB {
+			diff.WriteString("+ " + line + "\n")
+		}
+	} else {
+		writeLCSDiff(&diff, changedA, changedB)
+	}
+	for _, line := range a[len(a)-suffix:] {
+		diff.WriteString("  " + line + "\n")
+	}
+	return diff.String()
+}
+
+// writeLCSDiff writes the diff from a to b that keeps their longest common subsequence.
+func writeLCSDiff(diff *strings.Builder, a, b []string) {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			diff.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			diff.WriteString("+ " + b[j] + "\n")
+			j++
+		default:
+			diff.WriteString("- " + a[i] + "\n")
+			i++
+		}
+	}
+}
@@ -27,6 +27,14 @@ func IsValidRegister(user *entity.User) error {
 	return nil
 }
 
+// NewHashPassword validates a password with the same rules as registration and hashes it.
+func NewHashPassword(password string) (string, error) {
+	if err := isValidPassword(password); err != nil {
+		return "", err
+	}
+	return generateHashPassword(password)
+}
+
 func generateHashPassword(password string) (string, error) {
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	return string(hash), err
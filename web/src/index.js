@@ -53,6 +53,7 @@ const router = async () => {
         localStorage.setItem('role', roles.guest);
         localStorage.removeItem('id')
         localStorage.removeItem('token')
+        localStorage.removeItem('refresh_token')
     }
     const user = Utils.getUser()
 
@@ -31,6 +31,7 @@ const logOut = () => {
     localStorage.removeItem('id')
     localStorage.removeItem('role')
     localStorage.removeItem('token')
+    localStorage.removeItem('refresh_token')
 }
 
 const parseJwt = (token) => {
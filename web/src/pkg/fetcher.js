@@ -8,18 +8,16 @@ const fetcher = {
     post: async(path, body) =>{
         return makeRequest(path, body, "POST")
     },
+    put: async(path, body) =>{
+        return makeRequest(path, body, "PUT")
+    },
     checkToken: async() =>{
         const url = `http://${API_HOST_NAME}/api/is-valid`
         const options = {
             mode: 'cors',
             method: "GET",
-        }
-        const token = localStorage.getItem("token")
-        if (token != undefined){
-            options.headers = new Headers({
-                'Content-Type': 'application/json',
-                'Authorization': `Bearer ${token}`,
-            })
+            credentials: 'include',
+            headers: authHeaders(),
         }
         const response = await fetch(url, options).catch((e) =>{
             console.log(e)
@@ -37,24 +35,69 @@ const fetcher = {
             Utils.showError(response.status, responseBody.msg)
             return responseBody
         }
+        if (!responseBody.checker && await refreshTokens()){
+            return {checker: true}
+        }
         return responseBody
     }
 }
+
+// getCookie returns the value of a cookie that is readable from js
+const getCookie = (name) => {
+    const cookie = document.cookie.split("; ").find((c) => c.startsWith(name + "="))
+    return cookie ? cookie.substring(name.length + 1) : undefined
+}
+
+// authHeaders sends the bearer token, or the csrf token when the server uses cookies
+const authHeaders = () => {
+    const headers = new Headers({'Content-Type': 'application/json'})
+    const token = localStorage.getItem("token")
+    if (token != undefined){
+        headers.set('Authorization', `Bearer ${token}`)
+    }
+    const csrf = getCookie("csrf_token")
+    if (csrf != undefined){
+        headers.set('X-CSRF-Token', csrf)
+    }
+    return headers
+}
+
+// refreshTokens exchanges the stored refresh token (or the refresh cookie) for a new token pair
+const refreshTokens = async () => {
+    const refreshToken = localStorage.getItem("refresh_token")
+    if (refreshToken == undefined && getCookie("csrf_token") == undefined){
+        return false
+    }
+    const response = await fetch(`http://${API_HOST_NAME}/api/token/refresh`, {
+        mode: 'cors',
+        method: "POST",
+        credentials: 'include',
+        headers: authHeaders(),
+        body: JSON.stringify(refreshToken != undefined ? {"refresh_token": refreshToken} : {})
+    }).catch((e) =>{
+        console.log(e)
+    })
+    if (!response || !response.ok){
+        localStorage.removeItem("refresh_token")
+        return false
+    }
+    const data = await response.json()
+    if (data.token != undefined){
+        localStorage.setItem("token", data.token)
+        localStorage.setItem("refresh_token", data.refresh_token)
+    }
+    return true
+}
 // bad request: UNIQUE constraint failed: users.username
-const makeRequest = async(path, body, method) => {
+const makeRequest = async(path, body, method, retried = false) => {
     const url = `http://${API_HOST_NAME}${path}`
     const options = {
         mode: 'cors',
         method: method,
+        credentials: 'include',
+        headers: authHeaders(),
         body: JSON.stringify(body)
     }
-    const token = localStorage.getItem("token")
-    if (token != undefined){
-        options.headers = new Headers({
-            'Content-Type': 'application/json',
-            'Authorization': `Bearer ${token}`,
-        })
-    }
     const response = await fetch(url, options).catch((e) =>{
         console.log(e)
         Utils.showError(503)
@@ -68,7 +111,10 @@ const makeRequest = async(path, body, method) => {
         return
     }
 
-    if (response.status == 401 || response.status == 403) {
+    if (response.status == 401 && !retried && await refreshTokens()) {
+        return makeRequest(path, body, method, true)
+    }
+    if (response.status == 401) {
         Utils.logOut()
         redirect.navigateTo("/sign-in")
         return responseBody
@@ -77,7 +123,8 @@ const makeRequest = async(path, body, method) => {
         Utils.showError(response.status)
         return
     }
-    if (response.status == 400){
+    // forbidden actions, like those that need more reputation, are shown by the view
+    if (response.status == 400 || response.status == 403 || response.status == 409){
         return responseBody
     }
     if (!response.ok){
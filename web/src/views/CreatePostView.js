@@ -5,21 +5,165 @@ import redirect from "../index.js";
 
 
 const path = "/api/post/create"
+const editPath = "/api/post/edit/"
+const draftPath = "/api/draft/"
+const draftsPath = "/api/drafts"
+const autosaveDelay = 10000
 
-const createPost = async (title, text, tags) => {
-    let body = {
-        "title" : title,
-        "data": text,
+let autosaveTimer
+
+// readForm returns the title, text, tags and poll of the form
+const readForm = () => {
+    const tags = [];
+    document.querySelectorAll(".btn-tag").forEach(tagElement => {
+        tags.push(tagElement.querySelector('span').textContent);
+    });
+    return {
+        "title" : document.getElementById("TitleInput").value,
+        "data": document.getElementById("TextInput").value,
         "tags" : tags,
+        "poll": readPoll(),
+    }
+}
+
+// readPoll returns the poll of the form, or undefined when it has no question
+const readPoll = () => {
+    const question = document.getElementById("PollQuestionInput").value
+    if (question.trim() === "") {
+        return undefined
+    }
+    const closesAt = document.getElementById("PollClosesAtInput").value
+    return {
+        "question": question,
+        "options": document.getElementById("PollOptionsInput").value.split("\n").filter(text => text.trim() !== "").map(text => ({"text": text})),
+        "multiple": document.getElementById("PollMultipleInput").checked,
+        "anonymous": document.getElementById("PollAnonymousInput").checked,
+        "closes_at": closesAt ? new Date(closesAt).toISOString() : null,
     }
+}
 
-    const data = await fetcher.post(path, body)
+// localDateTime formats a time for datetime-local inputs
+const localDateTime = (time) => {
+    const local = new Date(time)
+    local.setMinutes(local.getMinutes() - local.getTimezoneOffset())
+    return local.toISOString().slice(0, 16)
+}
+
+// savePost creates the post, or updates it once it was saved as a draft
+const savePost = async (draft, body) => {
+    const data = draft.id ? await fetcher.put(editPath + draft.id, body) : await fetcher.post(path, body)
+    let showErr = document.getElementById("showError")
     if (data && data.msg !== undefined){
-        let showErr = document.getElementById("showError")
-        showErr.innerHTML = data.msg
+        showErr.innerText = data.msg
+        return false
+    }
+    if (data && data.post_id) {
+        draft.id = data.post_id
+    }
+    showErr.innerText = ""
+    return true
+}
+
+const createPost = async (draft) => {
+    if (await savePost(draft, {...readForm(), "status": "published"})) {
+        redirect.navigateTo(`/post/${draft.id}`)
+    }
+}
+
+const saveDraft = async (draft) => {
+    const body = readForm()
+    if (await savePost(draft, {...body, "status": "draft"})) {
+        draft.status = "draft"
+        draft.saved = JSON.stringify(body)
+        document.getElementById("draft-status").innerText = "Draft saved at " + new Date().toLocaleTimeString()
+        drawDrafts()
+    }
+}
+
+const schedulePost = async (draft) => {
+    const publishAt = document.getElementById("PublishAtInput").value
+    if (!publishAt) {
+        document.getElementById("showError").innerText = "Choose when to publish the post"
+        return
+    }
+    const body = {...readForm(), "status": "scheduled", "publish_at": new Date(publishAt).toISOString()}
+    if (await savePost(draft, body)) {
+        draft.status = "scheduled"
+        document.getElementById("draft-status").innerText = "Scheduled for " + new Date(publishAt).toLocaleString()
+        drawDrafts()
+    }
+}
+
+// autosave saves changes of a draft in the background
+const autosave = (draft) => {
+    clearInterval(autosaveTimer)
+    autosaveTimer = setInterval(() => {
+        if (!document.getElementById("form-createPost")) {
+            clearInterval(autosaveTimer)
+            return
+        }
+        const body = readForm()
+        if (draft.status !== "draft" || JSON.stringify(body) === draft.saved) {
+            return
+        }
+        if (body.title === "" && body.data === "" && body.tags.length === 0 && !body.poll) {
+            return
+        }
+        saveDraft(draft)
+    }, autosaveDelay)
+}
+
+// loadDraft fills the form with a saved draft or scheduled post
+const loadDraft = async (draft) => {
+    const post = await fetcher.get(draftPath + draft.id)
+    if (!post || post.msg !== undefined) {
+        return []
+    }
+    draft.status = post.status
+    document.getElementById("TitleInput").value = post.title
+    document.getElementById("TextInput").value = post.data
+    if (post.publish_at) {
+        document.getElementById("PublishAtInput").value = localDateTime(post.publish_at)
+        document.getElementById("draft-status").innerText = "Scheduled for " + new Date(post.publish_at).toLocaleString()
+    }
+    if (post.poll) {
+        document.getElementById("PollQuestionInput").value = post.poll.question
+        document.getElementById("PollOptionsInput").value = post.poll.options.map(option => option.text).join("\n")
+        document.getElementById("PollMultipleInput").checked = post.poll.multiple
+        document.getElementById("PollAnonymousInput").checked = post.poll.anonymous
+        if (post.poll.closes_at) {
+            document.getElementById("PollClosesAtInput").value = localDateTime(post.poll.closes_at)
+        }
+    }
+    return post.tags.filter(tag => tag !== "ALL")
+}
+
+const drawDrafts = async () => {
+    const data = await fetcher.get(draftsPath)
+    const draftsEl = document.getElementById("drafts")
+    if (!draftsEl || !data || data.msg !== undefined) {
         return
     }
-    redirect.navigateTo(`/post/${data.post_id}`)
+    draftsEl.innerHTML = ""
+    if (data.posts.length > 0) {
+        const header = document.createElement("h5")
+        header.innerText = "Your drafts"
+        draftsEl.append(header)
+    }
+    for (const post of data.posts) {
+        const el = document.createElement("div")
+        const linkEl = document.createElement("a")
+        linkEl.setAttribute("href", `/create-post?draft=${post.post_id}`)
+        linkEl.setAttribute("data-link", "")
+        linkEl.innerText = post.title || "(untitled)"
+        el.append(linkEl)
+        if (post.status === "scheduled") {
+            const whenEl = document.createElement("span")
+            whenEl.innerText = " scheduled for " + new Date(post.publish_at).toLocaleString()
+            el.append(whenEl)
+        }
+        draftsEl.append(el)
+    }
 }
 
 export default class extends AbstractView{
@@ -127,34 +271,59 @@ export default class extends AbstractView{
         </div>
         <textarea type="text" name="tags" class="d-none" id="tb_TagEditor"></textarea>
         <div class="form-text">Maximum of 5 tags and length of 1 tag maximum of 16 characters</div>
+        <div class="mb-3">
+            <label for="PollQuestionInput" class="form-label">Poll</label>
+            <input maxlength="200" type="text" class="form-control" id="PollQuestionInput" placeholder="Question">
+            <textarea class="form-control" id="PollOptionsInput" rows="3" placeholder="One option per line"></textarea>
+            <div class="form-check">
+                <input class="form-check-input" type="checkbox" id="PollMultipleInput">
+                <label class="form-check-label" for="PollMultipleInput">Multiple choice</label>
+            </div>
+            <div class="form-check">
+                <input class="form-check-input" type="checkbox" id="PollAnonymousInput">
+                <label class="form-check-label" for="PollAnonymousInput">Anonymous</label>
+            </div>
+            <label for="PollClosesAtInput" class="form-label">Closes at</label>
+            <input type="datetime-local" class="form-control" id="PollClosesAtInput">
+            <div class="form-text">Leave the question empty for a post without a poll, 2 to 10 options</div>
+        </div>
+        <div class="mb-3">
+            <label for="PublishAtInput" class="form-label">Publish at</label>
+            <input type="datetime-local" class="form-control" id="PublishAtInput">
+        </div>
         <button class="btn btn-primary">Post</button>
+        <button class="btn btn-secondary" type="button" id="save-draft">Save draft</button>
+        <button class="btn btn-secondary" type="button" id="schedule">Schedule</button>
+        <div class="form-text" id="draft-status"></div>
         <div id="showError"></div>
         </form>
+        <div id="drafts"></div>
         </div>
     </main>
         `;
     }
     async init() {
+        const draft = {id: new URLSearchParams(location.search).get("draft"), status: "draft"}
+        let tags = ["LIGHT"]
+        if (draft.id) {
+            tags = await loadDraft(draft)
+        }
         const tagEditor = new TagEditor({
             BlockSelectorName: '#b_TagEditor',
             TextBlockSelectorName: '#tb_TagEditor',
             HasDoubles: false,
             ToLower: true,
             MaxTags: 5,
-            Tags: ["LIGHT"]
+            Tags: tags
         });
+        draft.saved = JSON.stringify(readForm())
         const signInForm = document.getElementById("form-createPost")
         signInForm.addEventListener("submit", function () {
-            const title = document.getElementById("TitleInput").value
-            const data = document.getElementById("TextInput").value
-            const tagElements = document.querySelectorAll(".btn-tag")
-            const tags = [];
-
-        tagElements.forEach(tagElement => {
-            const tagValue = tagElement.querySelector('span').textContent;
-            tags.push(tagValue);
-        });
-            createPost(title, data, tags)
+            createPost(draft)
         })
+        document.getElementById("save-draft").addEventListener("click", () => { saveDraft(draft) })
+        document.getElementById("schedule").addEventListener("click", () => { schedulePost(draft) })
+        autosave(draft)
+        drawDrafts()
     }
 };
\ No newline at end of file
@@ -3,19 +3,33 @@ import fetcher from "../pkg/fetcher.js";
 
 const path = `/api/posts/`
 
-const getPostsByCategory = async (category) =>{
-    const data = await fetcher.get(path + category)
+// sortQuery returns the sort and window query parameters of the selected feed order
+// and the unanswered filter
+const sortQuery = () => {
+    const sort = document.getElementById("sort").value
+    const [mode, win] = sort.split(":")
+    const unanswered = document.getElementById("unanswered").checked
+    return `sort=${mode}` + (win ? `&window=${win}` : "") + (unanswered ? "&unanswered=true" : "")
+}
+
+const getPostsByCategory = async (category, cursor = "") =>{
+    const data = await fetcher.get(path + category + "?" + sortQuery() + (cursor ? `&cursor=${cursor}` : ""))
     if (data && data.msg !== undefined){
         console.log(data)
         return
     }else{
         const postsDoc = document.getElementById("posts")
-        postsDoc.textContent = "";
-        for (let i = data.length - 1; i >= 0; i--) {
-            const post = data[i];
+        if (cursor === ""){
+            postsDoc.textContent = "";
+        }
+        for (let i = 0; i < data.posts.length; i++) {
+            const post = data.posts[i];
             const el = newPostElement(post);
             postsDoc.append(el);
         }
+        const moreEl = document.getElementById("more")
+        moreEl.hidden = data.next_cursor === ""
+        moreEl.onclick = () => getPostsByCategory(category, data.next_cursor)
     }
 }
 
@@ -27,13 +41,13 @@ const newPostElement = (post) =>{
     titleEl.classList.add("card-header")
     titleEl.setAttribute("href", `/post/${post.post_id}`)
     titleEl.setAttribute("data-link", "")
-    titleEl.innerText = "Title: " + post.title
+    titleEl.innerText = (post.pinned ? "[pinned] " : "") + "Title: " + post.title
 
     const authorEl = document.createElement("a")
     authorEl.classList.add("card-header")
     authorEl.setAttribute("href", `/user/${post.user_id}`)
     authorEl.setAttribute("data-link", "")
-    authorEl.innerText = "Author: " + post.username
+    authorEl.innerText = `Author: ${post.username} (${post.user_reputation})`
 
     const body = document.createElement("div")
     body.classList.add("card-body")
@@ -49,8 +63,13 @@ const newPostElement = (post) =>{
     dataEl.classList.add("card-text")
     dataEl.innerText = post.data.substring(0, 300)+"..."
 
+    const countsEl = document.createElement("small")
+    countsEl.classList.add("text-muted")
+    countsEl.innerText = `Likes: ${post.likes} Dislikes: ${post.dislikes} Comments: ${post.comment_count}`
+
     body.append(tagsEl)
     body.append(dataEl)
+    body.append(countsEl)
 
     el.append(titleEl)
     el.append(authorEl)
@@ -73,23 +92,40 @@ export default class extends AbstractView{
         </style>
         <header class="py-3 mb-4 border-bottom">
             <div class="container d-flex flex-wrap justify-content-center">
-            <form id="form-search" class="w-100 me-3" onsubmit="return false;">
+            <form id="form-search" class="w-100 me-3 d-flex" onsubmit="return false;">
                 <input id="search" type="search" class="form-control" placeholder="Search by category" aria-label="Search">
+                <select id="sort" class="form-select ms-2" style="max-width: 200px;" aria-label="Sort posts">
+                    <option value="new">New</option>
+                    <option value="hot">Hot</option>
+                    <option value="top:day">Top of the day</option>
+                    <option value="top:week">Top of the week</option>
+                    <option value="top:month">Top of the month</option>
+                    <option value="top:all">Top of all time</option>
+                    <option value="active">Most commented</option>
+                </select>
+                <div class="form-check ms-2 align-self-center text-nowrap">
+                    <input class="form-check-input" type="checkbox" id="unanswered">
+                    <label class="form-check-label" for="unanswered">Unanswered</label>
+                </div>
             </form>
             </div>
         </header>
         <div id="posts"></div>
+        <button id="more" class="btn btn-primary mt-3" hidden>Load more</button>
         `;
     }
     async init() {
         getPostsByCategory("ALL")
         const signInForm = document.getElementById("form-search")
-        signInForm.addEventListener("submit", function () {
+        const search = function () {
             let category = document.getElementById("search").value
             if (category.trim() === ""){
                 category = "ALL"
             }
             getPostsByCategory(category.trim())
-        })
+        }
+        signInForm.addEventListener("submit", search)
+        document.getElementById("sort").addEventListener("change", search)
+        document.getElementById("unanswered").addEventListener("change", search)
     }
 }
\ No newline at end of file
@@ -1,5 +1,6 @@
 import AbstractView from "./AbstractView.js";
 import Utils from "../pkg/Utils.js";
+import fetcher from "../pkg/fetcher.js";
 
 export default class extends AbstractView{
     constructor(params, user){
@@ -40,9 +41,9 @@ export default class extends AbstractView{
         );
     }
     async init() {
-        document.body.addEventListener('click', (event) => {
+        document.body.addEventListener('click', async (event) => {
             if (event.target.id === 'sign-out-button') {
-                console.log("log");
+                await fetcher.post("/api/signout")
                 Utils.logOut();
                 window.location.reload();
             }
@@ -4,7 +4,7 @@ import AbstractView from "./AbstractView.js";
 const getPostPath = "/api/post/"
 const sendCommentPath = "/api/comment/create"
 
-const getPost = async (postID) => {
+const getPost = async (postID, user) => {
     const post = await fetcher.get(getPostPath+postID)
     if (post && post.msg != undefined){
         console.log(post)
@@ -13,13 +13,26 @@ const getPost = async (postID) => {
     if (post) {
         document.getElementById("post-title").innerText = "Title: "+ post.title
         const userEl = document.getElementById("post-user-id")
-        userEl.innerText = "Author: " + post.username
+        userEl.innerText = `Author: ${post.username} (${post.user_reputation})`
         userEl.setAttribute("href", `/user/${post.user_id}`)
+        const retagBtn = document.getElementById("post-retag")
+        if (retagBtn) {
+            const tags = post.tags.filter(tag => tag !== "ALL")
+            retagBtn.addEventListener("click", () => {
+                const input = window.prompt("Tags, separated by commas", tags.join(", "))
+                if (input !== null) {
+                    retagPost(postID, input)
+                }
+            })
+        }
         for (let i = 0; i < post.tags.length; i++){
             post.tags[i] = " #" + post.tags[i] 
         }
         document.getElementById("post-tags").innerText = "Categories:" + post.tags.slice(0, -1)
-        document.getElementById("post-data").innerText = post.data
+        // data_html is rendered from Markdown and sanitized by the server
+        document.getElementById("post-data").innerHTML = post.data_html
+        drawAttachments(post.attachments)
+        drawPoll(post.poll)
         document.getElementById("post-like-inner").innerText = post.likes
         document.getElementById("post-dislike-inner").innerText = post.dislikes
 
@@ -35,9 +48,10 @@ const getPost = async (postID) => {
             commentText.innerText = "Comments: "
             commentsDoc.append(commentText)
         } 
-        for (let i = post.comments.length - 1; i >= 0; i--) {
-            const comment = post.comments[i];
-            const el = drawComments(comment);
+        // the post author can accept a top-level comment as the answer
+        const canAccept = user.id !== null && String(post.user_id) === user.id
+        for (const comment of post.comments) {
+            const el = drawComments(comment, postID, canAccept);
             commentsDoc.append(el);
         }
     }
@@ -51,6 +65,39 @@ const votePost = async (postID, likeType) =>{
     }
     const data = await fetcher.post(path, body)
     if (data && data.msg) {
+        showError(data.msg)
+        return
+    }
+    window.location.reload()
+}
+
+// showError shows the error of an action on the post, like a vote that needs more reputation
+const showError = (msg) => {
+    const errorEl = document.getElementById("post-error")
+    errorEl.innerText = msg
+}
+
+const retagPost = async (postID, tags) => {
+    const body = {
+        "post_id" : parseInt(postID),
+        "tags" : tags.split(",").map(tag => tag.trim()).filter(tag => tag !== "")
+    }
+    const data = await fetcher.post("/api/post/tags", body)
+    if (data && data.msg) {
+        showError(data.msg)
+        return
+    }
+    window.location.reload()
+}
+
+const acceptAnswer = async (postID, commentID) => {
+    const body = {
+        "post_id" : parseInt(postID),
+        "comment_id" : commentID
+    }
+    const data = await fetcher.post("/api/post/accept", body)
+    if (data && data.msg) {
+        showError(data.msg)
         return
     }
     window.location.reload()
@@ -64,27 +111,151 @@ const voteComment = async (commentID, likeType) =>{
     }
     const data = await fetcher.post(path, body)
     if (data && data.msg) {
+        showError(data.msg)
         return
     }
     window.location.reload()
 }
 
-const drawComments = (comment) => {
+// drawAttachments shows image thumbnails that link to the full image and links to other files
+const drawAttachments = (attachments) => {
+    const attachmentsEl = document.getElementById("post-attachments")
+    for (const attachment of attachments) {
+        const url = `http://${API_HOST_NAME}/api/attachments/${attachment.id}`
+        const linkEl = document.createElement("a")
+        linkEl.setAttribute("href", url)
+        linkEl.setAttribute("target", "_blank")
+        linkEl.classList.add("me-2")
+        if (attachment.has_thumbnail) {
+            const imgEl = document.createElement("img")
+            imgEl.setAttribute("src", url + "/thumbnail")
+            imgEl.setAttribute("alt", attachment.file_name)
+            imgEl.classList.add("img-thumbnail")
+            linkEl.append(imgEl)
+        } else {
+            linkEl.innerText = attachment.file_name
+        }
+        attachmentsEl.append(linkEl)
+    }
+}
+
+// drawPoll shows the question and results of the poll, with a ballot while it is open
+const drawPoll = (poll) => {
+    const pollEl = document.getElementById("post-poll")
+    pollEl.innerHTML = ""
+    if (!poll) {
+        return
+    }
+    const questionEl = document.createElement("h5")
+    questionEl.innerText = poll.question
+    pollEl.append(questionEl)
+    for (const option of poll.options) {
+        const optionEl = document.createElement("div")
+        optionEl.classList.add("form-check")
+        const inputEl = document.createElement("input")
+        inputEl.className = "form-check-input"
+        inputEl.setAttribute("type", poll.multiple ? "checkbox" : "radio")
+        inputEl.setAttribute("name", "poll-option")
+        inputEl.setAttribute("id", `poll-option-${option.id}`)
+        inputEl.value = option.id
+        inputEl.disabled = poll.closed
+        const labelEl = document.createElement("label")
+        labelEl.className = "form-check-label"
+        labelEl.setAttribute("for", `poll-option-${option.id}`)
+        const percent = poll.total_voters ? Math.round(option.votes * 100 / poll.total_voters) : 0
+        labelEl.innerText = `${option.text}: ${option.votes} (${percent}%)`
+        optionEl.append(inputEl, labelEl)
+        if (option.voters) {
+            const votersEl = document.createElement("div")
+            votersEl.className = "form-text"
+            votersEl.innerText = option.voters.join(", ")
+            optionEl.append(votersEl)
+        }
+        pollEl.append(optionEl)
+    }
+    const infoEl = document.createElement("div")
+    infoEl.className = "form-text"
+    const info = [`${poll.total_voters} voters`]
+    if (poll.multiple) {
+        info.push("multiple choice")
+    }
+    if (poll.anonymous) {
+        info.push("anonymous")
+    }
+    if (poll.closed) {
+        info.push("closed")
+    } else if (poll.closes_at) {
+        info.push("closes " + new Date(poll.closes_at).toLocaleString())
+    }
+    infoEl.innerText = info.join(", ")
+    pollEl.append(infoEl)
+    if (!poll.closed) {
+        const voteButton = document.createElement("button")
+        voteButton.className = "btn btn-outline-primary btn-sm"
+        voteButton.innerText = "Vote"
+        voteButton.addEventListener("click", () => { votePoll(poll.id) })
+        const errorEl = document.createElement("div")
+        errorEl.className = "error"
+        errorEl.setAttribute("id", "poll-error")
+        pollEl.append(voteButton, errorEl)
+    }
+}
+
+const votePoll = async (pollID) => {
+    const optionIDs = Array.from(document.querySelectorAll("input[name=poll-option]:checked")).map(el => parseInt(el.value))
+    const body = {
+        "poll_id" : pollID,
+        "option_ids" : optionIDs
+    }
+    const data = await fetcher.post("/api/poll/vote", body)
+    if (data && data.msg !== undefined) {
+        document.getElementById("poll-error").innerText = data.msg
+        return
+    }
+    if (data) {
+        drawPoll(data)
+    }
+}
+
+const drawComments = (comment, postID, canAccept) => {
     const el = document.createElement("div");
     el.classList.add("card")
+    el.style.marginLeft = `${comment.depth * 2}em`
+    if (comment.accepted) {
+        el.classList.add("border-success")
+    }
+
+    // deleted comments are kept for their replies, without author, votes or actions
+    if (comment.deleted) {
+        const headerEl = document.createElement("div")
+        headerEl.classList.add("card-header")
+        headerEl.innerText = "[deleted]"
+        const body = document.createElement("div")
+        body.classList.add("card-body")
+        body.innerHTML = comment.data_html
+        el.append(headerEl)
+        el.append(body)
+        return el
+    }
 
     const authorEl = document.createElement("a")
     authorEl.classList.add("card-header")
     authorEl.setAttribute("href", `/user/${comment.user_id}`)
     authorEl.setAttribute("data-link", "")
-    authorEl.innerText = "Author: " + comment.username
+    authorEl.innerText = `Author: ${comment.username} (${comment.user_reputation})`
+    if (comment.accepted) {
+        const badgeEl = document.createElement("span")
+        badgeEl.className = "badge bg-success ms-2"
+        badgeEl.innerText = "Accepted answer"
+        authorEl.append(badgeEl)
+    }
 
     const body = document.createElement("div")
     body.classList.add("card-body")
 
-    const dataEl = document.createElement("p")
+    const dataEl = document.createElement("div")
     dataEl.classList.add("card-text")
-    dataEl.innerText = comment.data
+    dataEl.innerHTML = comment.data_html
     body.append(dataEl)
 
     let likeButton = document.createElement("button");
@@ -111,6 +282,27 @@ const drawComments = (comment) => {
     votes.appendChild(likeButton)
     votes.appendChild(dislikeButton)
 
+    const replyButton = document.createElement("button");
+    replyButton.className = "btn btn-link";
+    replyButton.innerText = "Reply";
+    replyButton.addEventListener("click", () => {
+        const reply = window.prompt("Reply to " + comment.username)
+        if (reply) {
+            sendComment(reply, postID, comment.comment_id)
+        }
+    })
+    votes.appendChild(replyButton)
+
+    if (canAccept && comment.depth === 0) {
+        const acceptButton = document.createElement("button");
+        acceptButton.className = "btn btn-link";
+        acceptButton.innerText = comment.accepted ? "Unaccept" : "Accept answer";
+        acceptButton.addEventListener("click", () => {
+            acceptAnswer(postID, comment.accepted ? 0 : comment.comment_id)
+        })
+        votes.appendChild(acceptButton)
+    }
+
     likeButton.addEventListener("click", () => { voteComment(comment.comment_id, 1) })
     dislikeButton.addEventListener("click", () => { voteComment(comment.comment_id, 0) })
 
@@ -122,10 +314,11 @@ const drawComments = (comment) => {
     return el
 } 
 
-const sendComment = async (comment, postID)=> {
+const sendComment = async (comment, postID, parentID = 0)=> {
     let body = {
         "data" : comment,
-        "post_id" : parseInt(postID)
+        "post_id" : parseInt(postID),
+        "parent_id" : parentID
     }
     const data = await fetcher.post(sendCommentPath, body)
     if (data && data.msg !== undefined) {
@@ -198,14 +391,18 @@ export default class extends AbstractView{
                     <div id="post-user">
                     <a id="post-user-id"></a>
                     </div>
-                    <h5 id="post-tags"></h5>
+                    <h5 id="post-tags"></h5>` + (isAuthorized ? `
+                    <button class="btn btn-link p-0" id="post-retag">Edit tags</button>` : ``) + `
                     <hr>
                     <div class="col1">
-                        <p id="post-data"></p>
+                        <div id="post-data"></div>
+                        <div id="post-attachments"></div>
+                        <div id="post-poll"></div>
                     </div>
                     <hr>
                     <button class="btn post-like" id="post-like"><i class="fa fa-thumbs-up fa-lg" id="post-like-inner" aria-hidden="true"></i></button>
                     <button class="btn post-dislike" id="post-dislike"><i class="fa fa-thumbs-down fa-lg" id="post-dislike-inner" aria-hidden="true"></i></button>
+                    <div class="error" id="post-error"></div>
                 </div>
             </div>
         </div>
@@ -213,7 +410,7 @@ export default class extends AbstractView{
         <form class="comment-form" id="comment-form" onsubmit="return false;">
         <h3>Leave a comment here:</h3>
         <div class="mb-3">
-            <textarea class="form-control" id="comment-input" rows="3" placeholder="Leave a comment"></textarea>
+            <textarea class="form-control" id="comment-input" rows="3" maxlength="2000" placeholder="Leave a comment"></textarea>
         </div>
         <button class="w-100 btn btn-lg btn-primary" type="submit">Send</button>
             <div class="error" id="showError"></div>
@@ -225,7 +422,7 @@ export default class extends AbstractView{
     }
     async init() {
         const postID = this.params.postID
-        getPost(postID)
+        getPost(postID, this.user)
 
         const signInForm = document.getElementById("comment-form")
         if (signInForm){
@@ -16,56 +16,25 @@ const getUserByID = async (userID) => {
     }
 }
 
-const getUserLikedPosts = async (userID) =>{
-    const path =`/api/profile/liked-posts/${userID}`
-    const posts = await fetcher.get(path)
-    if (posts && posts.msg != undefined){
-        console.log(post)
-        return
-    }
-    if (posts){
-        const postsDoc = document.getElementById("posts")
-        postsDoc.textContent = "";
-        for (let i = posts.length - 1; i >= 0; i--) {
-            const post = posts[i];
-            const el = newPostElement(post);
-            postsDoc.append(el);
-        }
-    }
-}
-const getUserDislikedPosts = async (userID) =>{
-    const path =`/api/profile/disliked-posts/${userID}`
-    const posts = await fetcher.get(path)
-    if (posts && posts.msg != undefined){
-        console.log(post)
+const getUserPosts = async (path, cursor = "") =>{
+    const data = await fetcher.get(path + (cursor ? `?cursor=${cursor}` : ""))
+    if (data && data.msg != undefined){
+        console.log(data)
         return
     }
-    if (posts){
+    if (data){
         const postsDoc = document.getElementById("posts")
-        postsDoc.textContent = "";
-        for (let i = posts.length - 1; i >= 0; i--) {
-            const post = posts[i];
-            const el = newPostElement(post);
-            postsDoc.append(el);
+        if (cursor === ""){
+            postsDoc.textContent = "";
         }
-    }
-}
-
-const getUserPosts = async (userID) =>{
-    const path =`/api/profile/posts/${userID}`
-    const posts = await fetcher.get(path)
-    if (posts && posts.msg != undefined){
-        console.log(post)
-        return
-    }
-    if (posts){
-        const postsDoc = document.getElementById("posts")
-        postsDoc.textContent = "";
-        for (let i = posts.length - 1; i >= 0; i--) {
-            const post = posts[i];
+        for (let i = 0; i < data.posts.length; i++) {
+            const post = data.posts[i];
             const el = newPostElement(post);
             postsDoc.append(el);
         }
+        const moreEl = document.getElementById("more")
+        moreEl.hidden = data.next_cursor === ""
+        moreEl.onclick = () => getUserPosts(path, data.next_cursor)
     }
 }
 const newPostElement = (post) =>{
@@ -82,7 +51,7 @@ const newPostElement = (post) =>{
     authorEl.classList.add("card-header")
     authorEl.setAttribute("href", `/user/${post.user_id}`)
     authorEl.setAttribute("data-link", "")
-    authorEl.innerText = "Author: " + post.username
+    authorEl.innerText = `Author: ${post.username} (${post.user_reputation})`
 
     const body = document.createElement("div")
     body.classList.add("card-body")
@@ -109,6 +78,7 @@ const newPostElement = (post) =>{
 const drawUser = (user) =>{
     document.getElementById("username").innerText = user.username
     document.getElementById("email").innerText = user.email
+    document.getElementById("reputation").innerText = "Reputation: " + user.reputation
 }
 
 export default class extends AbstractView{
@@ -130,6 +100,7 @@ export default class extends AbstractView{
                 <div class="card-body">
                     <h5 id="username" class="card-header"></h5>
                     <h5 id="email" class="card-header"></h5>
+                    <h5 id="reputation" class="card-header"></h5>
                     <select id="options" class="form-select" aria-label="Default select example">
                         <option value="created">Created posts</option>
                         <option value="liked">Liked posts</option>
@@ -141,12 +112,13 @@ export default class extends AbstractView{
         </div>
         <hr>
         <div id="posts"></div>
+        <button id="more" class="btn btn-primary mt-3" hidden>Load more</button>
         `;
     }
     async init() {
         const userID = this.params.userID
         getUserByID(userID)
-        getUserPosts(userID)
+        getUserPosts(`/api/profile/posts/${userID}`)
         // Находим элемент select по его id
         let selectElement = document.getElementById("options");
 
@@ -157,11 +129,11 @@ export default class extends AbstractView{
             
             // Делаем что-то с полученным значением
             if (selectedValue == "created"){
-                getUserPosts(userID)
+                getUserPosts(`/api/profile/posts/${userID}`)
             } else if (selectedValue == "liked"){
-                getUserLikedPosts(userID)
+                getUserPosts(`/api/profile/liked-posts/${userID}`)
             } else if (selectedValue == "disliked") {
-                getUserDislikedPosts(userID)
+                getUserPosts(`/api/profile/disliked-posts/${userID}`)
             } else {
                 Utils.showError(400, "invalid option")
             }
@@ -17,9 +17,14 @@ const signIn = async (email, password) => {
         showErr.innerHTML = data.msg
         return
     }
-    localStorage.setItem("token", data.token)
-    const payload = Utils.parseJwt(data.token)
-    localStorage.setItem("id", payload.id)
+    if (data.token != undefined){
+        localStorage.setItem("token", data.token)
+        localStorage.setItem("refresh_token", data.refresh_token)
+        localStorage.setItem("id", Utils.parseJwt(data.token).id)
+    } else {
+        // cookie mode: the tokens are kept in HttpOnly cookies
+        localStorage.setItem("id", data.user_id)
+    }
     localStorage.setItem("role", redirect.roles.user)
     redirect.navigateTo('/')
 }